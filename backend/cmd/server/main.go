@@ -13,6 +13,7 @@ import (
 	"go-chat/internal/server"
 	"go-chat/internal/settings"
 	"go-chat/internal/storage/postgres"
+	"go-chat/internal/usernames"
 	"go-chat/internal/utils"
 
 	"github.com/aaronkim218/eventsocket"
@@ -41,6 +42,15 @@ func main() {
 		DbUrl: settings.Storage.DbUrl,
 	})
 
+	usernamePolicy, err := usernames.NewPolicy(&usernames.Config{
+		AllowedPattern: settings.Username.AllowedPattern,
+		Reserved:       settings.Username.Reserved,
+	})
+	if err != nil {
+		slog.Error("failed to create username policy", "error", err)
+		os.Exit(1)
+	}
+
 	mem := memory.New()
 
 	eventsocket := eventsocket.New()
@@ -51,6 +61,7 @@ func main() {
 		Logger: slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
 			Level: utils.MustParseSlogLevel(settings.Hub.LogLevel),
 		})),
+		UsernamePolicy: usernamePolicy,
 	})
 
 	app := server.New(&server.Config{
@@ -62,6 +73,7 @@ func main() {
 		FiberStorage:     mem,
 		Eventsocket:      eventsocket,
 		PluginsContainer: pluginsContainer,
+		UsernamePolicy:   usernamePolicy,
 	})
 
 	go func() {
@@ -83,5 +95,7 @@ func main() {
 			slog.String("error", err.Error()))
 	}
 
+	pluginsContainer.Stop()
+
 	slog.Info("server shutdown")
 }
@@ -0,0 +1,13 @@
+package constants
+
+const (
+	// MaxAvatarUploadBytes stays below fiber's default body limit
+	MaxAvatarUploadBytes int = 2 * 1024 * 1024
+	MaxAvatarDimension   int = 4096
+	DefaultAvatarSize    int = 128
+	AvatarContentType        = "image/png"
+	AvatarCacheControl       = "public, max-age=31536000, immutable"
+)
+
+// AvatarSizes are the square sizes, in pixels, that uploaded avatars are resized to
+var AvatarSizes = []int{64, 128, 256}
@@ -0,0 +1,7 @@
+package constants
+
+import "time"
+
+// ContactRequestCooldown is how long a requester has to wait before sending
+// another request to a user who declined their last one
+const ContactRequestCooldown = 7 * 24 * time.Hour
@@ -1,7 +1,9 @@
 package constants
 
 const (
-	HeaderKeyVary string = "Vary"
+	HeaderKeyVary         string = "Vary"
+	HeaderKeyWorkspaceId  string = "X-Workspace-Id"
+	HeaderKeyConnectionId string = "X-Connection-Id"
 )
 
 const (
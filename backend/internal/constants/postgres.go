@@ -1,8 +1,17 @@
 package constants
 
 const (
-	RoomsHostFKeyConstraint          string = "rooms_host_fkey"
-	ProfilesUsernameUniqueConstraint string = "profiles_username_key"
-	ProfilesPKeyUniqueConstraint     string = "profiles_pkey"
-	UsersRoomsUserIdFKeyConstraint   string = "users_rooms_user_id_fkey"
+	RoomsHostFKeyConstraint                  string = "rooms_host_fkey"
+	ProfilesUsernameUniqueConstraint         string = "profiles_username_key"
+	ProfilesUsernameLowerUniqueConstraint    string = "profiles_username_lower_key"
+	ProfilesUsernameSkeletonUniqueConstraint string = "profiles_username_skeleton_key"
+	ProfilesPKeyUniqueConstraint             string = "profiles_pkey"
+	UsersRoomsUserIdFKeyConstraint           string = "users_rooms_user_id_fkey"
+	DmsUserAFKeyConstraint                   string = "dms_user_a_fkey"
+	DmsUserBFKeyConstraint                   string = "dms_user_b_fkey"
+	UserBlocksPKeyUniqueConstraint           string = "user_blocks_pkey"
+	UserBlocksBlockedFKeyConstraint          string = "user_blocks_blocked_fkey"
+	ContactsPKeyUniqueConstraint             string = "contacts_pkey"
+	ContactsPairUniqueConstraint             string = "contacts_pair_idx"
+	ContactsRecipientFKeyConstraint          string = "contacts_recipient_fkey"
 )
@@ -0,0 +1,9 @@
+package constants
+
+import "time"
+
+const (
+	PresenceIdleTimeout     = 5 * time.Minute
+	PresenceSweepInterval   = 30 * time.Second
+	MaxPresenceStatusLength = 128
+)
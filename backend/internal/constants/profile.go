@@ -1,6 +1,10 @@
 package constants
 
 const (
-	MinUsernameLength int = 4
-	MaxUsernameLength int = 16
+	MinUsernameLength    int = 4
+	MaxUsernameLength    int = 16
+	MaxBioLength         int = 512
+	MaxPronounsLength    int = 32
+	MaxProfileLinks      int = 5
+	MaxProfileLinkLength int = 256
 )
@@ -0,0 +1,16 @@
+package constants
+
+import "time"
+
+const (
+	MaxRoomNameLength        int = 64
+	MaxRoomTopicLength       int = 256
+	MaxRoomDescriptionLength int = 2048
+	MaxRoomAvatarLength      int = 512
+	MaxRoomBanReasonLength   int = 512
+	MaxRoomSlowModeSeconds   int = 6 * 60 * 60
+)
+
+const (
+	MaxRoomMuteDuration = 30 * 24 * time.Hour
+)
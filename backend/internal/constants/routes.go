@@ -2,4 +2,5 @@ package constants
 
 const (
 	SearchProfiles string = "/api/profiles/search"
+	Avatars        string = "/api/avatars"
 )
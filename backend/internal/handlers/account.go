@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"go-chat/internal/constants"
+	"go-chat/internal/protocol"
+	"go-chat/internal/xcontext"
+	"go-chat/internal/xerrors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// DeleteProfile permanently deletes the user's account and disconnects their live clients
+func (hs *HandlerService) DeleteProfile(c *fiber.Ctx) error {
+	uid, err := xcontext.GetUserId(c)
+	if err != nil {
+		return err
+	}
+
+	if err := hs.storage.DeleteAccount(c.Context(), uid); err != nil {
+		return err
+	}
+
+	// the event is written to the socket directly so it is not lost when the connection closes, and closing the socket
+	// lets the read loop remove the client from eventsocket
+	if conn, connected := hs.connections.get(uid); connected {
+		frame, err := protocol.NewFrame(protocol.AccountDeletedEvent, accountDeletedEvent{UserId: uid})
+		if err == nil {
+			err = conn.WriteJSON(frame)
+		}
+
+		if err != nil {
+			hs.logger.Error("Failed to notify deleted account",
+				slog.String("err", err.Error()),
+				slog.String("userId", uid.String()),
+			)
+		}
+
+		if err := conn.Close(); err != nil {
+			hs.logger.Error("Failed to close deleted account connection",
+				slog.String("err", err.Error()),
+				slog.String("userId", uid.String()),
+			)
+		}
+	}
+
+	return c.SendStatus(http.StatusNoContent)
+}
+
+// ExportProfile returns a zip archive of everything stored about the user
+func (hs *HandlerService) ExportProfile(c *fiber.Ctx) error {
+	uid, err := xcontext.GetUserId(c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Context()
+
+	profile, err := hs.storage.GetDetailedProfileByUserId(ctx, uid)
+	if err != nil {
+		return err
+	}
+
+	workspaces, err := hs.storage.GetWorkspacesByUserId(ctx, uid)
+	if err != nil {
+		return err
+	}
+
+	rooms, err := hs.storage.GetRoomMembershipsByUserId(ctx, uid)
+	if err != nil {
+		return err
+	}
+
+	messages, err := hs.storage.GetMessagesByAuthor(ctx, uid)
+	if err != nil {
+		return err
+	}
+
+	contacts, err := hs.storage.GetContactProfiles(ctx, uid)
+	if err != nil {
+		return err
+	}
+
+	contactRequests, err := hs.storage.GetContactRequests(ctx, uid)
+	if err != nil {
+		return err
+	}
+
+	blocked, err := hs.storage.GetBlockedProfiles(ctx, uid)
+	if err != nil {
+		return err
+	}
+
+	invitations, err := hs.storage.GetPendingInvitationsByUserId(ctx, uid)
+	if err != nil {
+		return err
+	}
+
+	preferences, err := hs.storage.GetPreferences(ctx, uid)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+
+	files := []struct {
+		name string
+		data any
+	}{
+		{"profile.json", profile},
+		{"preferences.json", preferences.Values},
+		{"workspaces.json", workspaces},
+		{"rooms.json", rooms},
+		{"messages.json", messages},
+		{"contacts.json", contacts},
+		{"contact_requests.json", contactRequests},
+		{"blocked_profiles.json", blocked},
+		{"invitations.json", invitations},
+	}
+
+	for _, file := range files {
+		data, err := json.MarshalIndent(file.data, "", "  ")
+		if err != nil {
+			return xerrors.InternalServerError()
+		}
+
+		if err := writeZipFile(archive, file.name, data); err != nil {
+			return xerrors.InternalServerError()
+		}
+	}
+
+	// the avatar is optional, an avatar url without a stored image is left out rather than failing the export
+	if profile.AvatarUrl != "" {
+		avatar, err := hs.storage.GetProfileAvatar(ctx, uid, slices.Max(constants.AvatarSizes))
+		if err != nil && !xerrors.IsNotFound(err) {
+			return err
+		}
+
+		if err == nil {
+			if err := writeZipFile(archive, "avatar.png", avatar.Data); err != nil {
+				return xerrors.InternalServerError()
+			}
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return xerrors.InternalServerError()
+	}
+
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s-export-%s.zip"`, profile.Username, time.Now().UTC().Format("20060102")))
+
+	return c.Status(http.StatusOK).Send(buf.Bytes())
+}
+
+func writeZipFile(archive *zip.Writer, name string, data []byte) error {
+	w, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+	return err
+}
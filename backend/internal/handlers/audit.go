@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"go-chat/internal/models"
+	"go-chat/internal/types"
+	"go-chat/internal/xcontext"
+	"go-chat/internal/xerrors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+func (hs *HandlerService) GetAuditLogByRoomId(c *fiber.Ctx) error {
+	uid, err := xcontext.GetUserId(c)
+	if err != nil {
+		return err
+	}
+
+	ridStr := c.Params("roomId")
+
+	rid, err := uuid.Parse(ridStr)
+	if err != nil {
+		return xerrors.BadRequestError(fmt.Sprintf("invalid room id: %s", ridStr))
+	}
+
+	var opts types.AuditLogOptions
+	if err := c.QueryParser(&opts); err != nil {
+		return xerrors.BadRequestError("failed to parse query parameters")
+	}
+
+	if errMap := opts.Validate(); len(errMap) > 0 {
+		return xerrors.UnprocessableEntityError(errMap)
+	}
+
+	// the log outlives the room, so the hosts and admins of a deleted room can still read it
+	canRead, err := hs.storage.CheckUserCanReadAuditLog(c.Context(), rid, uid)
+	if err != nil {
+		return err
+	}
+
+	if !canRead {
+		return xerrors.ForbiddenError("only the host or an admin can perform this action")
+	}
+
+	entries, err := hs.storage.GetAuditLogByRoomId(c.Context(), opts, rid)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(entries)
+}
+
+// recordAudit appends an entry to the room's audit log. The action has already happened by the time this is called
+// so failures are logged rather than returned.
+func (hs *HandlerService) recordAudit(ctx context.Context, roomId uuid.UUID, actorId uuid.UUID, action models.AuditAction, target *uuid.UUID, details any) {
+	logError := func(err error) {
+		hs.logger.Error("Failed to record audit entry",
+			slog.String("err", err.Error()),
+			slog.String("action", string(action)),
+			slog.String("roomId", roomId.String()),
+			slog.String("userId", actorId.String()),
+		)
+	}
+
+	if details == nil {
+		details = struct{}{}
+	}
+
+	data, err := json.Marshal(details)
+	if err != nil {
+		logError(err)
+		return
+	}
+
+	entryId, err := uuid.NewRandom()
+	if err != nil {
+		logError(err)
+		return
+	}
+
+	entry := models.AuditEntry{
+		Id:        entryId,
+		RoomId:    roomId,
+		Actor:     &actorId,
+		Action:    action,
+		Target:    target,
+		Details:   data,
+		CreatedAt: time.Now(),
+	}
+
+	if err := hs.storage.CreateAuditEntry(ctx, entry); err != nil {
+		logError(err)
+	}
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"go-chat/internal/constants"
+	"go-chat/internal/utils"
+	"go-chat/internal/xcontext"
+	"go-chat/internal/xerrors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+var allowedAvatarTypes = []string{"image/png", "image/jpeg", "image/gif"}
+
+func (hs *HandlerService) UploadAvatar(c *fiber.Ctx) error {
+	uid, err := xcontext.GetUserId(c)
+	if err != nil {
+		return err
+	}
+
+	fileHeader, err := c.FormFile("avatar")
+	if err != nil {
+		return xerrors.BadRequestError("avatar file is required")
+	}
+
+	if fileHeader.Size > int64(constants.MaxAvatarUploadBytes) {
+		return xerrors.UnprocessableEntityError(map[string]string{
+			"avatar": fmt.Sprintf("avatar must be at most %d bytes", constants.MaxAvatarUploadBytes),
+		})
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return xerrors.BadRequestError("failed to read avatar file")
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, int64(constants.MaxAvatarUploadBytes)+1))
+	if err != nil {
+		return xerrors.BadRequestError("failed to read avatar file")
+	}
+
+	images, errMap := resizeAvatar(data)
+	if len(errMap) > 0 {
+		return xerrors.UnprocessableEntityError(errMap)
+	}
+
+	now := time.Now()
+	avatarUrl := fmt.Sprintf("%s/%s?v=%d", constants.Avatars, uid, now.Unix())
+
+	if err := hs.storage.SetProfileAvatar(c.Context(), uid, images, avatarUrl, now); err != nil {
+		return err
+	}
+
+	hs.publishProfile(c.Context(), uid)
+
+	profile, err := hs.storage.GetProfileByUserId(c.Context(), uid)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(profile)
+}
+
+func (hs *HandlerService) DeleteAvatar(c *fiber.Ctx) error {
+	uid, err := xcontext.GetUserId(c)
+	if err != nil {
+		return err
+	}
+
+	if err := hs.storage.SetProfileAvatar(c.Context(), uid, nil, "", time.Now()); err != nil {
+		return err
+	}
+
+	hs.publishProfile(c.Context(), uid)
+
+	return c.SendStatus(http.StatusNoContent)
+}
+
+func (hs *HandlerService) GetAvatar(c *fiber.Ctx) error {
+	uidStr := c.Params("userId")
+
+	uid, err := uuid.Parse(uidStr)
+	if err != nil {
+		return xerrors.BadRequestError(fmt.Sprintf("invalid user id: %s", uidStr))
+	}
+
+	size := constants.DefaultAvatarSize
+	if sizeStr := c.Query("size"); sizeStr != "" {
+		size, err = strconv.Atoi(sizeStr)
+		if err != nil || !slices.Contains(constants.AvatarSizes, size) {
+			return xerrors.BadRequestError(fmt.Sprintf("size must be one of %v", constants.AvatarSizes))
+		}
+	}
+
+	avatar, err := hs.storage.GetProfileAvatar(c.Context(), uid, size)
+	if err != nil {
+		return err
+	}
+
+	etag := fmt.Sprintf(`"%s-%d-%d"`, uid, size, avatar.UpdatedAt.UnixNano())
+
+	c.Set(fiber.HeaderCacheControl, constants.AvatarCacheControl)
+	c.Set(fiber.HeaderETag, etag)
+	c.Set(fiber.HeaderLastModified, avatar.UpdatedAt.UTC().Format(http.TimeFormat))
+
+	if c.Get(fiber.HeaderIfNoneMatch) == etag {
+		return c.SendStatus(http.StatusNotModified)
+	}
+
+	c.Set(fiber.HeaderContentType, constants.AvatarContentType)
+
+	return c.Status(http.StatusOK).Send(avatar.Data)
+}
+
+// resizeAvatar validates an uploaded image and encodes it as a png at every avatar size
+func resizeAvatar(data []byte) (map[int][]byte, map[string]string) {
+	if len(data) > constants.MaxAvatarUploadBytes {
+		return nil, map[string]string{
+			"avatar": fmt.Sprintf("avatar must be at most %d bytes", constants.MaxAvatarUploadBytes),
+		}
+	}
+
+	if !slices.Contains(allowedAvatarTypes, http.DetectContentType(data)) {
+		return nil, map[string]string{"avatar": "avatar must be a png, jpeg or gif image"}
+	}
+
+	// check the dimensions before decoding so a small file cannot expand into a huge image
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, map[string]string{"avatar": "avatar could not be decoded"}
+	}
+
+	if config.Width > constants.MaxAvatarDimension || config.Height > constants.MaxAvatarDimension {
+		return nil, map[string]string{
+			"avatar": fmt.Sprintf("avatar dimensions must be at most %dx%d", constants.MaxAvatarDimension, constants.MaxAvatarDimension),
+		}
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, map[string]string{"avatar": "avatar could not be decoded"}
+	}
+
+	resized := utils.ResizeSquare(img, constants.AvatarSizes...)
+
+	images := make(map[int][]byte, len(constants.AvatarSizes))
+	for i, size := range constants.AvatarSizes {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, resized[i]); err != nil {
+			return nil, map[string]string{"avatar": "avatar could not be encoded"}
+		}
+
+		images[size] = buf.Bytes()
+	}
+
+	return images, nil
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"go-chat/internal/constants"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodePNG(t *testing.T, width int, height int) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			img.SetRGBA(x, y, color.RGBA{R: 255, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+
+	return buf.Bytes()
+}
+
+func TestResizeAvatar(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		wantErrs map[string]string
+	}{
+		{
+			name:     "valid png",
+			data:     encodePNG(t, 300, 200),
+			wantErrs: nil,
+		},
+		{
+			name: "too large",
+			data: make([]byte, constants.MaxAvatarUploadBytes+1),
+			wantErrs: map[string]string{
+				"avatar": fmt.Sprintf("avatar must be at most %d bytes", constants.MaxAvatarUploadBytes),
+			},
+		},
+		{
+			name:     "not an image",
+			data:     []byte("hello there"),
+			wantErrs: map[string]string{"avatar": "avatar must be a png, jpeg or gif image"},
+		},
+		{
+			name:     "truncated image",
+			data:     encodePNG(t, 8, 8)[:32],
+			wantErrs: map[string]string{"avatar": "avatar could not be decoded"},
+		},
+		{
+			name: "dimensions too large",
+			data: encodePNG(t, constants.MaxAvatarDimension+1, 1),
+			wantErrs: map[string]string{
+				"avatar": fmt.Sprintf("avatar dimensions must be at most %dx%d", constants.MaxAvatarDimension, constants.MaxAvatarDimension),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			images, errMap := resizeAvatar(tt.data)
+			assert.Equal(t, tt.wantErrs, errMap)
+
+			if tt.wantErrs != nil {
+				assert.Nil(t, images)
+				return
+			}
+
+			require.Len(t, images, len(constants.AvatarSizes))
+			for _, size := range constants.AvatarSizes {
+				img, err := png.Decode(bytes.NewReader(images[size]))
+				require.NoError(t, err)
+				assert.Equal(t, image.Rect(0, 0, size, size), img.Bounds())
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"go-chat/internal/models"
+	"go-chat/internal/xcontext"
+	"go-chat/internal/xerrors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+func (hs *HandlerService) GetBlockedProfiles(c *fiber.Ctx) error {
+	uid, err := xcontext.GetUserId(c)
+	if err != nil {
+		return err
+	}
+
+	profiles, err := hs.storage.GetBlockedProfiles(c.Context(), uid)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(profiles)
+}
+
+func (hs *HandlerService) BlockUser(c *fiber.Ctx) error {
+	uid, err := xcontext.GetUserId(c)
+	if err != nil {
+		return err
+	}
+
+	bidStr := c.Params("userId")
+
+	bid, err := uuid.Parse(bidStr)
+	if err != nil {
+		return xerrors.BadRequestError(fmt.Sprintf("invalid user id: %s", bidStr))
+	}
+
+	if bid == uid {
+		return xerrors.BadRequestError("cannot block yourself")
+	}
+
+	block := models.Block{
+		Blocker:   uid,
+		Blocked:   bid,
+		CreatedAt: time.Now(),
+	}
+
+	if err := hs.storage.BlockUser(c.Context(), block); err != nil {
+		return err
+	}
+
+	hs.syncPresencePeers(c.Context(), uid)
+
+	return c.Status(http.StatusCreated).JSON(block)
+}
+
+func (hs *HandlerService) UnblockUser(c *fiber.Ctx) error {
+	uid, err := xcontext.GetUserId(c)
+	if err != nil {
+		return err
+	}
+
+	bidStr := c.Params("userId")
+
+	bid, err := uuid.Parse(bidStr)
+	if err != nil {
+		return xerrors.BadRequestError(fmt.Sprintf("invalid user id: %s", bidStr))
+	}
+
+	if err := hs.storage.UnblockUser(c.Context(), uid, bid); err != nil {
+		return err
+	}
+
+	hs.syncPresencePeers(c.Context(), uid)
+
+	return c.SendStatus(http.StatusNoContent)
+}
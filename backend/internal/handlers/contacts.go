@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"go-chat/internal/constants"
+	"go-chat/internal/models"
+	"go-chat/internal/protocol"
+	"go-chat/internal/xcontext"
+	"go-chat/internal/xerrors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+func (hs *HandlerService) GetContacts(c *fiber.Ctx) error {
+	uid, err := xcontext.GetUserId(c)
+	if err != nil {
+		return err
+	}
+
+	profiles, err := hs.storage.GetContactProfiles(c.Context(), uid)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(profiles)
+}
+
+func (hs *HandlerService) RemoveContact(c *fiber.Ctx) error {
+	uid, err := xcontext.GetUserId(c)
+	if err != nil {
+		return err
+	}
+
+	cidStr := c.Params("userId")
+
+	cid, err := uuid.Parse(cidStr)
+	if err != nil {
+		return xerrors.BadRequestError(fmt.Sprintf("invalid user id: %s", cidStr))
+	}
+
+	if err := hs.storage.DeleteContact(c.Context(), uid, cid); err != nil {
+		return err
+	}
+
+	return c.SendStatus(http.StatusNoContent)
+}
+
+func (hs *HandlerService) GetContactRequests(c *fiber.Ctx) error {
+	uid, err := xcontext.GetUserId(c)
+	if err != nil {
+		return err
+	}
+
+	requests, err := hs.storage.GetContactRequests(c.Context(), uid)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(requests)
+}
+
+func (hs *HandlerService) SendContactRequest(c *fiber.Ctx) error {
+	uid, err := xcontext.GetUserId(c)
+	if err != nil {
+		return err
+	}
+
+	ridStr := c.Params("userId")
+
+	rid, err := uuid.Parse(ridStr)
+	if err != nil {
+		return xerrors.BadRequestError(fmt.Sprintf("invalid user id: %s", ridStr))
+	}
+
+	if rid == uid {
+		return xerrors.BadRequestError("cannot add yourself as a contact")
+	}
+
+	profile, err := hs.storage.GetProfileByUserId(c.Context(), uid)
+	if err != nil {
+		return err
+	}
+
+	contact, err := hs.storage.CreateContactRequest(c.Context(), models.Contact{
+		Requester: uid,
+		Recipient: rid,
+		Status:    models.ContactStatusPending,
+		CreatedAt: time.Now(),
+	}, constants.ContactRequestCooldown)
+	if err != nil {
+		return err
+	}
+
+	// sending a request to someone who already sent one accepts theirs
+	if contact.Status == models.ContactStatusAccepted {
+		hs.sendToUser(rid, protocol.ContactAcceptedEvent, contactEvent{
+			Contact: contact,
+			Profile: profile,
+		})
+
+		return c.Status(http.StatusOK).JSON(contact)
+	}
+
+	hs.sendToUser(rid, protocol.ContactRequestEvent, contactEvent{
+		Contact: contact,
+		Profile: profile,
+	})
+
+	return c.Status(http.StatusCreated).JSON(contact)
+}
+
+func (hs *HandlerService) AcceptContactRequest(c *fiber.Ctx) error {
+	uid, err := xcontext.GetUserId(c)
+	if err != nil {
+		return err
+	}
+
+	ridStr := c.Params("userId")
+
+	rid, err := uuid.Parse(ridStr)
+	if err != nil {
+		return xerrors.BadRequestError(fmt.Sprintf("invalid user id: %s", ridStr))
+	}
+
+	profile, err := hs.storage.GetProfileByUserId(c.Context(), uid)
+	if err != nil {
+		return err
+	}
+
+	contact, err := hs.storage.AcceptContactRequest(c.Context(), rid, uid)
+	if err != nil {
+		return err
+	}
+
+	hs.sendToUser(rid, protocol.ContactAcceptedEvent, contactEvent{
+		Contact: contact,
+		Profile: profile,
+	})
+
+	return c.Status(http.StatusOK).JSON(contact)
+}
+
+// DeleteContactRequest declines an incoming request or cancels an outgoing one
+func (hs *HandlerService) DeleteContactRequest(c *fiber.Ctx) error {
+	uid, err := xcontext.GetUserId(c)
+	if err != nil {
+		return err
+	}
+
+	oidStr := c.Params("userId")
+
+	oid, err := uuid.Parse(oidStr)
+	if err != nil {
+		return xerrors.BadRequestError(fmt.Sprintf("invalid user id: %s", oidStr))
+	}
+
+	if err := hs.storage.DeleteContactRequest(c.Context(), uid, oid, time.Now()); err != nil {
+		return err
+	}
+
+	return c.SendStatus(http.StatusNoContent)
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"go-chat/internal/models"
+	"go-chat/internal/xcontext"
+	"go-chat/internal/xerrors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+func (hs *HandlerService) GetOrCreateDm(c *fiber.Ctx) error {
+	uid, err := xcontext.GetUserId(c)
+	if err != nil {
+		return err
+	}
+
+	pidStr := c.Params("userId")
+
+	pid, err := uuid.Parse(pidStr)
+	if err != nil {
+		return xerrors.BadRequestError(fmt.Sprintf("invalid user id: %s", pidStr))
+	}
+
+	if pid == uid {
+		return xerrors.BadRequestError("cannot start a dm with yourself")
+	}
+
+	wid, err := hs.currentWorkspaceId(c)
+	if err != nil {
+		return err
+	}
+
+	// dms are not tied to a workspace but can only be started with someone sharing the current one
+	peerInWorkspace, err := hs.storage.CheckUserInWorkspace(c.Context(), wid, pid)
+	if err != nil {
+		return err
+	}
+
+	if !peerInWorkspace {
+		return xerrors.NotFoundError("profile", map[string]string{
+			"user_id": pid.String(),
+		})
+	}
+
+	workspace, err := hs.storage.GetWorkspaceById(c.Context(), wid)
+	if err != nil {
+		return err
+	}
+
+	if workspace.DmsContactsOnly {
+		isContact, err := hs.storage.CheckUsersAreContacts(c.Context(), uid, pid)
+		if err != nil {
+			return err
+		}
+
+		if !isContact {
+			return xerrors.ForbiddenError("dms in this workspace are limited to contacts")
+		}
+	}
+
+	// blocks are reported as a missing profile so the blocked user cannot tell they were blocked
+	blocked, err := hs.storage.CheckUsersBlocked(c.Context(), uid, pid)
+	if err != nil {
+		return err
+	}
+
+	if blocked {
+		return xerrors.NotFoundError("profile", map[string]string{
+			"user_id": pid.String(),
+		})
+	}
+
+	roomId, err := uuid.NewRandom()
+	if err != nil {
+		return xerrors.InternalServerError()
+	}
+
+	room := models.Room{
+		Id:         roomId,
+		Host:       &uid,
+		Kind:       models.RoomKindDm,
+		Visibility: models.RoomVisibilityPrivate,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+
+	userRoom, created, err := hs.storage.GetOrCreateDmRoom(c.Context(), room, uid, pid)
+	if err != nil {
+		return err
+	}
+
+	if created {
+		hs.pluginsContainer.Presence.LinkPeers(uid.String(), []string{pid.String()})
+		return c.Status(http.StatusCreated).JSON(userRoom)
+	}
+
+	return c.Status(http.StatusOK).JSON(userRoom)
+}
@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"go-chat/internal/constants"
+	"go-chat/internal/models"
+	"go-chat/internal/protocol"
+	"go-chat/internal/types"
+
+	"github.com/aaronkim218/eventsocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type memberJoinedEvent struct {
+	RoomId  uuid.UUID      `json:"room_id"`
+	Profile models.Profile `json:"profile"`
+}
+
+type roomUpdatedEvent struct {
+	Room    models.Room       `json:"room"`
+	Message types.UserMessage `json:"message"`
+}
+
+type contactEvent struct {
+	Contact models.Contact `json:"contact"`
+	Profile models.Profile `json:"profile"`
+}
+
+type accountDeletedEvent struct {
+	UserId uuid.UUID `json:"user_id"`
+}
+
+type moderationEvent struct {
+	RoomId    uuid.UUID  `json:"room_id"`
+	UserId    uuid.UUID  `json:"user_id"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// broadcastToRoom sends an event to every client currently joined to the room, if any
+func (hs *HandlerService) broadcastToRoom(roomId uuid.UUID, eventType string, payload any) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		hs.logger.Error("Failed to marshal event",
+			slog.String("err", err.Error()),
+			slog.String("type", eventType),
+			slog.String("roomId", roomId.String()),
+		)
+		return
+	}
+
+	message := eventsocket.Message{
+		Type: eventType,
+		Data: data,
+	}
+
+	if err := hs.eventsocket.BroadcastToRoom(roomId.String(), message); err != nil && !errors.Is(err, eventsocket.ErrRoomNotFound) {
+		hs.logger.Error("Failed to broadcast event",
+			slog.String("err", err.Error()),
+			slog.String("type", eventType),
+			slog.String("roomId", roomId.String()),
+		)
+	}
+}
+
+// sendToUser sends an event to the user's connected client, if any
+func (hs *HandlerService) sendToUser(userId uuid.UUID, eventType string, payload any) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		hs.logger.Error("Failed to marshal event",
+			slog.String("err", err.Error()),
+			slog.String("type", eventType),
+			slog.String("userId", userId.String()),
+		)
+		return
+	}
+
+	message := eventsocket.Message{
+		Type: eventType,
+		Data: data,
+	}
+
+	if err := hs.eventsocket.BroadcastToClient(userId.String(), message); err != nil && !errors.Is(err, eventsocket.ErrClientNotFound) {
+		hs.logger.Error("Failed to send event",
+			slog.String("err", err.Error()),
+			slog.String("type", eventType),
+			slog.String("userId", userId.String()),
+		)
+	}
+}
+
+// sendToOtherConnections sends the event to the user unless their connection is the one the request came from, as
+// named by the X-Connection-Id header
+func (hs *HandlerService) sendToOtherConnections(c *fiber.Ctx, userId uuid.UUID, eventType string, payload any) {
+	if hs.connections.isOrigin(userId, c.Get(constants.HeaderKeyConnectionId)) {
+		return
+	}
+
+	hs.sendToUser(userId, eventType, payload)
+}
+
+func (hs *HandlerService) broadcastMemberJoined(ctx context.Context, roomId uuid.UUID, userId uuid.UUID) {
+	profile, err := hs.storage.GetProfileByUserId(ctx, userId)
+	if err != nil {
+		hs.logger.Error("Failed to get profile for member joined event",
+			slog.String("err", err.Error()),
+			slog.String("userId", userId.String()),
+			slog.String("roomId", roomId.String()),
+		)
+		return
+	}
+
+	hs.broadcastToRoom(roomId, protocol.MemberJoinedEvent, memberJoinedEvent{
+		RoomId:  roomId,
+		Profile: profile,
+	})
+	hs.syncPresencePeers(ctx, userId)
+}
+
+// publishProfile pushes the user's current profile to the plugins and the
+// rooms they are active in after it changes
+func (hs *HandlerService) publishProfile(ctx context.Context, userId uuid.UUID) {
+	profile, err := hs.storage.GetProfileByUserId(ctx, userId)
+	if err != nil {
+		hs.logger.Error("Failed to get profile for profile update",
+			slog.String("err", err.Error()),
+			slog.String("userId", userId.String()),
+		)
+		return
+	}
+
+	hs.pluginsContainer.UpdateProfile(profile)
+}
+
+// syncPresencePeers links the user's presence with everyone they share a room
+// or dm with, and unlinks it from anyone they no longer do or have blocked
+func (hs *HandlerService) syncPresencePeers(ctx context.Context, userId uuid.UUID) {
+	peerIds, err := hs.storage.GetRoomPeerIds(ctx, userId)
+	if err != nil {
+		hs.logger.Error("Failed to get room peers for presence",
+			slog.String("err", err.Error()),
+			slog.String("userId", userId.String()),
+		)
+		return
+	}
+
+	peers := make(map[string]struct{}, len(peerIds))
+	linkIds := make([]string, 0, len(peerIds))
+	for _, peerId := range peerIds {
+		peers[peerId.String()] = struct{}{}
+		linkIds = append(linkIds, peerId.String())
+	}
+
+	var stale []string
+	for _, linkedId := range hs.pluginsContainer.Presence.LinkedPeers(userId.String()) {
+		if _, exists := peers[linkedId]; !exists {
+			stale = append(stale, linkedId)
+		}
+	}
+
+	hs.pluginsContainer.Presence.UnlinkPeers(userId.String(), stale)
+	hs.pluginsContainer.Presence.LinkPeers(userId.String(), linkIds)
+}
+
+// recordSystemMessage stores a system message authored by the acting user in the room history
+func (hs *HandlerService) recordSystemMessage(ctx context.Context, roomId uuid.UUID, actor models.Profile, content string) (types.UserMessage, error) {
+	messageId, err := uuid.NewRandom()
+	if err != nil {
+		return types.UserMessage{}, err
+	}
+
+	message := models.Message{
+		Id:        messageId,
+		RoomId:    roomId,
+		Author:    &actor.UserId,
+		Content:   content,
+		Kind:      models.MessageKindSystem,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	if err := hs.storage.CreateMessage(ctx, message); err != nil {
+		return types.UserMessage{}, err
+	}
+
+	return types.UserMessage{
+		Message:   message,
+		Username:  actor.Username,
+		FirstName: actor.FirstName,
+		LastName:  actor.LastName,
+		AvatarUrl: actor.AvatarUrl,
+	}, nil
+}
@@ -5,6 +5,7 @@ import (
 
 	"go-chat/internal/plugins"
 	"go-chat/internal/storage"
+	"go-chat/internal/usernames"
 
 	"github.com/MicahParks/keyfunc/v2"
 	"github.com/aaronkim218/eventsocket"
@@ -20,6 +21,8 @@ type HandlerService struct {
 	fiberStorage     fiber.Storage
 	eventsocket      *eventsocket.Eventsocket
 	pluginsContainer *plugins.Container
+	usernamePolicy   *usernames.Policy
+	connections      *connections
 }
 
 type HandlerServiceConfig struct {
@@ -29,6 +32,7 @@ type HandlerServiceConfig struct {
 	FiberStorage     fiber.Storage
 	Eventsocket      *eventsocket.Eventsocket
 	PluginsContainer *plugins.Container
+	UsernamePolicy   *usernames.Policy
 }
 
 func NewService(cfg *HandlerServiceConfig) *HandlerService {
@@ -44,5 +48,7 @@ func NewService(cfg *HandlerServiceConfig) *HandlerService {
 		fiberStorage:     cfg.FiberStorage,
 		eventsocket:      cfg.Eventsocket,
 		pluginsContainer: cfg.PluginsContainer,
+		usernamePolicy:   cfg.UsernamePolicy,
+		connections:      newConnections(),
 	}
 }
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"go-chat/internal/models"
+	"go-chat/internal/protocol"
+	"go-chat/internal/types"
+	"go-chat/internal/xcontext"
+	"go-chat/internal/xerrors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+func (hs *HandlerService) InviteUsersToRoom(c *fiber.Ctx) error {
+	uid, err := xcontext.GetUserId(c)
+	if err != nil {
+		return err
+	}
+
+	type request struct {
+		UserIds []uuid.UUID `json:"user_ids"`
+	}
+
+	ridStr := c.Params("roomId")
+
+	rid, err := uuid.Parse(ridStr)
+	if err != nil {
+		return xerrors.BadRequestError(fmt.Sprintf("invalid room id: %s", ridStr))
+	}
+
+	var req request
+	if err := c.BodyParser(&req); err != nil {
+		return xerrors.InvalidJSON()
+	}
+
+	inRoom, err := hs.storage.CheckUserInRoom(c.Context(), rid, uid)
+	if err != nil {
+		return err
+	}
+
+	if !inRoom {
+		return xerrors.NotFoundError("room", map[string]string{
+			"id": rid.String(),
+		})
+	}
+
+	room, err := hs.getWritableRoom(c, rid)
+	if err != nil {
+		return err
+	}
+
+	if room.Kind == models.RoomKindDm {
+		return xerrors.BadRequestError("cannot invite users to a dm")
+	}
+
+	result, err := hs.inviteUsers(c, room, uid, req.UserIds)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(result)
+}
+
+// inviteUsers creates pending invitations to the room on behalf of the inviter
+// and notifies every user that was invited. Membership is only ever granted
+// once the invitee accepts.
+func (hs *HandlerService) inviteUsers(c *fiber.Ctx, room models.Room, inviterId uuid.UUID, userIds []uuid.UUID) (types.BulkResult[uuid.UUID], error) {
+	if len(userIds) == 0 {
+		return types.BulkResult[uuid.UUID]{}, nil
+	}
+
+	inviter, err := hs.storage.GetProfileByUserId(c.Context(), inviterId)
+	if err != nil {
+		return types.BulkResult[uuid.UUID]{}, err
+	}
+
+	invitations := make(map[uuid.UUID]models.Invitation, len(userIds))
+	for _, inviteeId := range userIds {
+		invitationId, err := uuid.NewRandom()
+		if err != nil {
+			return types.BulkResult[uuid.UUID]{}, xerrors.InternalServerError()
+		}
+
+		invitations[inviteeId] = models.Invitation{
+			Id:        invitationId,
+			RoomId:    room.Id,
+			Inviter:   inviterId,
+			Invitee:   inviteeId,
+			Status:    models.InvitationStatusPending,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+	}
+
+	batch := make([]models.Invitation, 0, len(invitations))
+	for _, invitation := range invitations {
+		batch = append(batch, invitation)
+	}
+
+	result, err := hs.storage.CreateInvitations(c.Context(), batch)
+	if err != nil {
+		return types.BulkResult[uuid.UUID]{}, err
+	}
+
+	for _, inviteeId := range result.Successes {
+		hs.sendToUser(inviteeId, protocol.RoomInvitationEvent, types.PendingInvitation{
+			Invitation:     invitations[inviteeId],
+			RoomName:       room.Name,
+			InviterProfile: inviter,
+		})
+	}
+
+	return result, nil
+}
+
+func (hs *HandlerService) GetPendingInvitations(c *fiber.Ctx) error {
+	uid, err := xcontext.GetUserId(c)
+	if err != nil {
+		return err
+	}
+
+	invitations, err := hs.storage.GetPendingInvitationsByUserId(c.Context(), uid)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(invitations)
+}
+
+func (hs *HandlerService) AcceptInvitation(c *fiber.Ctx) error {
+	uid, err := xcontext.GetUserId(c)
+	if err != nil {
+		return err
+	}
+
+	iidStr := c.Params("invitationId")
+
+	iid, err := uuid.Parse(iidStr)
+	if err != nil {
+		return xerrors.BadRequestError(fmt.Sprintf("invalid invitation id: %s", iidStr))
+	}
+
+	invitation, err := hs.storage.AcceptInvitation(c.Context(), iid, uid, time.Now())
+	if err != nil {
+		return err
+	}
+
+	hs.recordAudit(c.Context(), invitation.RoomId, uid, models.AuditActionMemberJoined, nil, map[string]any{
+		"via":     "invitation",
+		"inviter": invitation.Inviter,
+	})
+	hs.broadcastMemberJoined(c.Context(), invitation.RoomId, uid)
+
+	return c.Status(http.StatusOK).JSON(invitation)
+}
+
+func (hs *HandlerService) DeclineInvitation(c *fiber.Ctx) error {
+	uid, err := xcontext.GetUserId(c)
+	if err != nil {
+		return err
+	}
+
+	iidStr := c.Params("invitationId")
+
+	iid, err := uuid.Parse(iidStr)
+	if err != nil {
+		return xerrors.BadRequestError(fmt.Sprintf("invalid invitation id: %s", iidStr))
+	}
+
+	if err := hs.storage.DeclineInvitation(c.Context(), iid, uid, time.Now()); err != nil {
+		return err
+	}
+
+	return c.SendStatus(http.StatusNoContent)
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"go-chat/internal/models"
+	"go-chat/internal/types"
+	"go-chat/internal/utils"
+	"go-chat/internal/xcontext"
+	"go-chat/internal/xerrors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+func (hs *HandlerService) CreateInvite(c *fiber.Ctx) error {
+	uid, err := xcontext.GetUserId(c)
+	if err != nil {
+		return err
+	}
+
+	ridStr := c.Params("roomId")
+
+	rid, err := uuid.Parse(ridStr)
+	if err != nil {
+		return xerrors.BadRequestError(fmt.Sprintf("invalid room id: %s", ridStr))
+	}
+
+	var opts types.InviteOptions
+	if err := c.BodyParser(&opts); err != nil {
+		return xerrors.InvalidJSON()
+	}
+
+	if errMap := opts.Validate(); len(errMap) > 0 {
+		return xerrors.UnprocessableEntityError(errMap)
+	}
+
+	if err := hs.requireRoomAdmin(c, rid, uid); err != nil {
+		return err
+	}
+
+	room, err := hs.getWritableRoom(c, rid)
+	if err != nil {
+		return err
+	}
+
+	if room.Kind == models.RoomKindDm {
+		return xerrors.BadRequestError("cannot create invites for a dm")
+	}
+
+	code, err := utils.GenerateInviteCode()
+	if err != nil {
+		return xerrors.InternalServerError()
+	}
+
+	invite := models.Invite{
+		Code:      code,
+		RoomId:    rid,
+		CreatedBy: uid,
+		MaxUses:   opts.MaxUses,
+		ExpiresAt: opts.ExpiresAt,
+		CreatedAt: time.Now(),
+	}
+
+	if err := hs.storage.CreateInvite(c.Context(), invite); err != nil {
+		return err
+	}
+
+	hs.recordAudit(c.Context(), rid, uid, models.AuditActionInviteCreated, nil, map[string]any{
+		"code":       invite.Code,
+		"max_uses":   invite.MaxUses,
+		"expires_at": invite.ExpiresAt,
+	})
+
+	return c.Status(http.StatusCreated).JSON(invite)
+}
+
+func (hs *HandlerService) GetInvitesByRoomId(c *fiber.Ctx) error {
+	uid, err := xcontext.GetUserId(c)
+	if err != nil {
+		return err
+	}
+
+	ridStr := c.Params("roomId")
+
+	rid, err := uuid.Parse(ridStr)
+	if err != nil {
+		return xerrors.BadRequestError(fmt.Sprintf("invalid room id: %s", ridStr))
+	}
+
+	if err := hs.requireRoomAdmin(c, rid, uid); err != nil {
+		return err
+	}
+
+	invites, err := hs.storage.GetInvitesByRoomId(c.Context(), rid)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(invites)
+}
+
+func (hs *HandlerService) RevokeInvite(c *fiber.Ctx) error {
+	uid, err := xcontext.GetUserId(c)
+	if err != nil {
+		return err
+	}
+
+	ridStr := c.Params("roomId")
+
+	rid, err := uuid.Parse(ridStr)
+	if err != nil {
+		return xerrors.BadRequestError(fmt.Sprintf("invalid room id: %s", ridStr))
+	}
+
+	if err := hs.requireRoomAdmin(c, rid, uid); err != nil {
+		return err
+	}
+
+	code := c.Params("code")
+
+	if err := hs.storage.DeleteInvite(c.Context(), rid, code); err != nil {
+		return err
+	}
+
+	hs.recordAudit(c.Context(), rid, uid, models.AuditActionInviteRevoked, nil, map[string]any{
+		"code": code,
+	})
+
+	return c.SendStatus(http.StatusNoContent)
+}
+
+func (hs *HandlerService) RedeemInvite(c *fiber.Ctx) error {
+	uid, err := xcontext.GetUserId(c)
+	if err != nil {
+		return err
+	}
+
+	code := c.Params("code")
+	if code == "" {
+		return xerrors.BadRequestError("invite code is required")
+	}
+
+	room, err := hs.storage.RedeemInvite(c.Context(), code, uid)
+	if err != nil {
+		return err
+	}
+
+	hs.recordAudit(c.Context(), room.Id, uid, models.AuditActionMemberJoined, nil, map[string]any{
+		"via":  "invite",
+		"code": code,
+	})
+	hs.broadcastMemberJoined(c.Context(), room.Id, uid)
+
+	return c.Status(http.StatusOK).JSON(room)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 
+	"go-chat/internal/models"
 	"go-chat/internal/xcontext"
 	"go-chat/internal/xerrors"
 
@@ -24,9 +25,14 @@ func (hs *HandlerService) DeleteMessageById(c *fiber.Ctx) error {
 		return xerrors.BadRequestError(fmt.Sprintf("invalid user id: %s", midStr))
 	}
 
-	if err := hs.storage.DeleteMessageById(c.Context(), mid, uid); err != nil {
+	rid, err := hs.storage.DeleteMessageById(c.Context(), mid, uid)
+	if err != nil {
 		return err
 	}
 
+	hs.recordAudit(c.Context(), rid, uid, models.AuditActionMessageDeleted, nil, map[string]any{
+		"message_id": mid,
+	})
+
 	return c.SendStatus(http.StatusNoContent)
 }
@@ -0,0 +1,303 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"go-chat/internal/models"
+	"go-chat/internal/protocol"
+	"go-chat/internal/types"
+	"go-chat/internal/xcontext"
+	"go-chat/internal/xerrors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+func (hs *HandlerService) GetBansByRoomId(c *fiber.Ctx) error {
+	uid, err := xcontext.GetUserId(c)
+	if err != nil {
+		return err
+	}
+
+	ridStr := c.Params("roomId")
+
+	rid, err := uuid.Parse(ridStr)
+	if err != nil {
+		return xerrors.BadRequestError(fmt.Sprintf("invalid room id: %s", ridStr))
+	}
+
+	if err := hs.requireRoomAdmin(c, rid, uid); err != nil {
+		return err
+	}
+
+	bans, err := hs.storage.GetBansByRoomId(c.Context(), rid)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(bans)
+}
+
+func (hs *HandlerService) BanUserFromRoom(c *fiber.Ctx) error {
+	uid, err := xcontext.GetUserId(c)
+	if err != nil {
+		return err
+	}
+
+	ridStr := c.Params("roomId")
+
+	rid, err := uuid.Parse(ridStr)
+	if err != nil {
+		return xerrors.BadRequestError(fmt.Sprintf("invalid room id: %s", ridStr))
+	}
+
+	tidStr := c.Params("userId")
+
+	tid, err := uuid.Parse(tidStr)
+	if err != nil {
+		return xerrors.BadRequestError(fmt.Sprintf("invalid user id: %s", tidStr))
+	}
+
+	var opts types.BanOptions
+	if err := c.BodyParser(&opts); err != nil {
+		return xerrors.InvalidJSON()
+	}
+
+	if errMap := opts.Validate(); len(errMap) > 0 {
+		return xerrors.UnprocessableEntityError(errMap)
+	}
+
+	if err := hs.requireCanModerate(c, rid, uid, tid); err != nil {
+		return err
+	}
+
+	ban := models.RoomBan{
+		RoomId:    rid,
+		UserId:    tid,
+		BannedBy:  &uid,
+		Reason:    opts.Reason,
+		CreatedAt: time.Now(),
+	}
+
+	if err := hs.storage.BanUserFromRoom(c.Context(), ban); err != nil {
+		return err
+	}
+
+	hs.recordAudit(c.Context(), rid, uid, models.AuditActionMemberBanned, &tid, map[string]any{
+		"reason": ban.Reason,
+	})
+
+	event := moderationEvent{
+		RoomId: rid,
+		UserId: tid,
+	}
+
+	// the banned user is told before their client is dropped from the room so they can update their view
+	hs.broadcastToRoom(rid, protocol.MemberBannedEvent, event)
+	hs.sendToUser(tid, protocol.MemberBannedEvent, event)
+	hs.eventsocket.RemoveClientFromRoom(rid.String(), tid.String())
+	hs.syncPresencePeers(c.Context(), tid)
+
+	return c.Status(http.StatusCreated).JSON(ban)
+}
+
+func (hs *HandlerService) UnbanUserFromRoom(c *fiber.Ctx) error {
+	uid, err := xcontext.GetUserId(c)
+	if err != nil {
+		return err
+	}
+
+	ridStr := c.Params("roomId")
+
+	rid, err := uuid.Parse(ridStr)
+	if err != nil {
+		return xerrors.BadRequestError(fmt.Sprintf("invalid room id: %s", ridStr))
+	}
+
+	tidStr := c.Params("userId")
+
+	tid, err := uuid.Parse(tidStr)
+	if err != nil {
+		return xerrors.BadRequestError(fmt.Sprintf("invalid user id: %s", tidStr))
+	}
+
+	if err := hs.requireRoomAdmin(c, rid, uid); err != nil {
+		return err
+	}
+
+	if err := hs.storage.UnbanUserFromRoom(c.Context(), rid, tid); err != nil {
+		return err
+	}
+
+	hs.recordAudit(c.Context(), rid, uid, models.AuditActionMemberUnbanned, &tid, nil)
+
+	event := moderationEvent{
+		RoomId: rid,
+		UserId: tid,
+	}
+
+	hs.broadcastToRoom(rid, protocol.MemberUnbannedEvent, event)
+	hs.sendToUser(tid, protocol.MemberUnbannedEvent, event)
+
+	return c.SendStatus(http.StatusNoContent)
+}
+
+func (hs *HandlerService) GetMutesByRoomId(c *fiber.Ctx) error {
+	uid, err := xcontext.GetUserId(c)
+	if err != nil {
+		return err
+	}
+
+	ridStr := c.Params("roomId")
+
+	rid, err := uuid.Parse(ridStr)
+	if err != nil {
+		return xerrors.BadRequestError(fmt.Sprintf("invalid room id: %s", ridStr))
+	}
+
+	if err := hs.requireRoomAdmin(c, rid, uid); err != nil {
+		return err
+	}
+
+	mutes, err := hs.storage.GetMutesByRoomId(c.Context(), rid)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(mutes)
+}
+
+func (hs *HandlerService) MuteUserInRoom(c *fiber.Ctx) error {
+	uid, err := xcontext.GetUserId(c)
+	if err != nil {
+		return err
+	}
+
+	ridStr := c.Params("roomId")
+
+	rid, err := uuid.Parse(ridStr)
+	if err != nil {
+		return xerrors.BadRequestError(fmt.Sprintf("invalid room id: %s", ridStr))
+	}
+
+	tidStr := c.Params("userId")
+
+	tid, err := uuid.Parse(tidStr)
+	if err != nil {
+		return xerrors.BadRequestError(fmt.Sprintf("invalid user id: %s", tidStr))
+	}
+
+	var opts types.MuteOptions
+	if err := c.BodyParser(&opts); err != nil {
+		return xerrors.InvalidJSON()
+	}
+
+	if errMap := opts.Validate(); len(errMap) > 0 {
+		return xerrors.UnprocessableEntityError(errMap)
+	}
+
+	if err := hs.requireCanModerate(c, rid, uid, tid); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	mute := models.RoomMute{
+		RoomId:    rid,
+		UserId:    tid,
+		MutedBy:   &uid,
+		ExpiresAt: now.Add(time.Duration(opts.Duration) * time.Second),
+		CreatedAt: now,
+	}
+
+	if err := hs.storage.MuteUserInRoom(c.Context(), mute); err != nil {
+		return err
+	}
+
+	hs.recordAudit(c.Context(), rid, uid, models.AuditActionMemberMuted, &tid, map[string]any{
+		"expires_at": mute.ExpiresAt,
+	})
+
+	hs.broadcastToRoom(rid, protocol.MemberMutedEvent, moderationEvent{
+		RoomId:    rid,
+		UserId:    tid,
+		ExpiresAt: &mute.ExpiresAt,
+	})
+
+	return c.Status(http.StatusOK).JSON(mute)
+}
+
+func (hs *HandlerService) UnmuteUserInRoom(c *fiber.Ctx) error {
+	uid, err := xcontext.GetUserId(c)
+	if err != nil {
+		return err
+	}
+
+	ridStr := c.Params("roomId")
+
+	rid, err := uuid.Parse(ridStr)
+	if err != nil {
+		return xerrors.BadRequestError(fmt.Sprintf("invalid room id: %s", ridStr))
+	}
+
+	tidStr := c.Params("userId")
+
+	tid, err := uuid.Parse(tidStr)
+	if err != nil {
+		return xerrors.BadRequestError(fmt.Sprintf("invalid user id: %s", tidStr))
+	}
+
+	if err := hs.requireRoomAdmin(c, rid, uid); err != nil {
+		return err
+	}
+
+	if err := hs.storage.UnmuteUserInRoom(c.Context(), rid, tid); err != nil {
+		return err
+	}
+
+	hs.recordAudit(c.Context(), rid, uid, models.AuditActionMemberUnmuted, &tid, nil)
+
+	hs.broadcastToRoom(rid, protocol.MemberUnmutedEvent, moderationEvent{
+		RoomId: rid,
+		UserId: tid,
+	})
+
+	return c.SendStatus(http.StatusNoContent)
+}
+
+// requireCanModerate checks that the actor may ban or mute the target in the room. Admins can moderate members
+// while only the host can moderate admins, and the host can never be moderated.
+func (hs *HandlerService) requireCanModerate(c *fiber.Ctx, roomId uuid.UUID, actorId uuid.UUID, targetId uuid.UUID) error {
+	if actorId == targetId {
+		return xerrors.BadRequestError("cannot moderate yourself")
+	}
+
+	if err := hs.requireRoomAdmin(c, roomId, actorId); err != nil {
+		return err
+	}
+
+	room, err := hs.getWritableRoom(c, roomId)
+	if err != nil {
+		return err
+	}
+
+	if room.Kind == models.RoomKindDm {
+		return xerrors.BadRequestError("cannot moderate a dm")
+	}
+
+	if room.Host != nil && *room.Host == targetId {
+		return xerrors.ForbiddenError("the host cannot be moderated")
+	}
+
+	targetIsAdmin, err := hs.storage.CheckUserIsRoomAdmin(c.Context(), roomId, targetId)
+	if err != nil {
+		return err
+	}
+
+	if targetIsAdmin && (room.Host == nil || *room.Host != actorId) {
+		return xerrors.ForbiddenError("only the host can moderate an admin")
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go-chat/internal/protocol"
+	"go-chat/internal/types"
+	"go-chat/internal/xcontext"
+	"go-chat/internal/xerrors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func (hs *HandlerService) GetPreferences(c *fiber.Ctx) error {
+	uid, err := xcontext.GetUserId(c)
+	if err != nil {
+		return err
+	}
+
+	preferences, err := hs.storage.GetPreferences(c.Context(), uid)
+	if err != nil {
+		return err
+	}
+
+	etag := preferences.ETag()
+	c.Set(fiber.HeaderETag, etag)
+
+	if ifNoneMatch(c.Get(fiber.HeaderIfNoneMatch), etag) {
+		return c.SendStatus(http.StatusNotModified)
+	}
+
+	return c.Status(http.StatusOK).JSON(preferences)
+}
+
+// PutPreferences applies a json merge patch to the user's preferences. An If-Match header makes the update
+// conditional on the preferences not having changed since that ETag was read.
+func (hs *HandlerService) PutPreferences(c *fiber.Ctx) error {
+	uid, err := xcontext.GetUserId(c)
+	if err != nil {
+		return err
+	}
+
+	var patch types.PreferencesPatch
+	if err := c.BodyParser(&patch); err != nil {
+		return xerrors.InvalidJSON()
+	}
+
+	if errMap := patch.Validate(); len(errMap) > 0 {
+		return xerrors.UnprocessableEntityError(errMap)
+	}
+
+	expectedVersion, err := parseIfMatch(c.Get(fiber.HeaderIfMatch))
+	if err != nil {
+		return err
+	}
+
+	preferences, err := hs.storage.PatchPreferences(c.Context(), patch, expectedVersion, uid)
+	if err != nil {
+		return err
+	}
+
+	hs.sendToOtherConnections(c, uid, protocol.PreferencesUpdatedEvent, preferences)
+
+	c.Set(fiber.HeaderETag, preferences.ETag())
+
+	return c.Status(http.StatusOK).JSON(preferences)
+}
+
+// parseIfMatch returns the preferences version required by an If-Match header, or nil when any version is accepted.
+// If-Match compares strongly, so a weak etag can never satisfy it.
+func parseIfMatch(header string) (*int64, error) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return nil, nil
+	}
+
+	if strings.HasPrefix(header, "W/") {
+		return nil, xerrors.PreconditionFailedError("weak etags cannot satisfy If-Match")
+	}
+
+	version, err := strconv.ParseInt(strings.Trim(header, `"`), 10, 64)
+	if err != nil {
+		return nil, xerrors.BadRequestError("invalid If-Match header")
+	}
+
+	return &version, nil
+}
+
+// ifNoneMatch reports whether an If-None-Match header matches the etag, comparing weakly as the header may list
+// several etags, mark them weak or be a wildcard
+func ifNoneMatch(header string, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
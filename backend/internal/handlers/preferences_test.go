@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"go-chat/internal/xerrors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	version := int64(3)
+
+	tests := []struct {
+		name        string
+		header      string
+		wantVersion *int64
+		wantStatus  int
+	}{
+		{name: "missing", header: "", wantVersion: nil},
+		{name: "wildcard", header: "*", wantVersion: nil},
+		{name: "strong etag", header: `"3"`, wantVersion: &version},
+		{name: "weak etag", header: `W/"3"`, wantStatus: http.StatusPreconditionFailed},
+		{name: "malformed", header: `"three"`, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseIfMatch(tt.header)
+
+			if tt.wantStatus != 0 {
+				var httpErr xerrors.HTTPError
+				assert.ErrorAs(t, err, &httpErr)
+				assert.Equal(t, tt.wantStatus, httpErr.StatusCode)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantVersion, got)
+		})
+	}
+}
+
+func TestIfNoneMatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   bool
+	}{
+		{name: "missing", header: "", want: false},
+		{name: "strong match", header: `"3"`, want: true},
+		{name: "weak match", header: `W/"3"`, want: true},
+		{name: "list", header: `"1", W/"3"`, want: true},
+		{name: "wildcard", header: "*", want: true},
+		{name: "no match", header: `"2"`, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ifNoneMatch(tt.header, `"3"`))
+		})
+	}
+}
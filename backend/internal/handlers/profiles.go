@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"fmt"
+	"maps"
 	"net/http"
 	"time"
 
@@ -20,7 +21,7 @@ func (hs *HandlerService) GetProfileByUserId(c *fiber.Ctx) error {
 		return err
 	}
 
-	profile, err := hs.storage.GetProfileByUserId(c.Context(), uid)
+	profile, err := hs.storage.GetDetailedProfileByUserId(c.Context(), uid)
 	if err != nil {
 		return err
 	}
@@ -40,7 +41,7 @@ func (hs *HandlerService) GetForeignProfileByUserId(c *fiber.Ctx) error {
 		return xerrors.BadRequestError(fmt.Sprintf("invalid room id: %s", pidStr))
 	}
 
-	profile, err := hs.storage.GetProfileByUserId(c.Context(), pid)
+	profile, err := hs.storage.GetDetailedProfileByUserId(c.Context(), pid)
 	if err != nil {
 		return err
 	}
@@ -59,7 +60,12 @@ func (hs *HandlerService) PatchProfileByUserId(c *fiber.Ctx) error {
 		return xerrors.InvalidJSON()
 	}
 
-	if errMap := partial.Validate(); len(errMap) > 0 {
+	errMap := partial.Validate()
+	if partial.Username != nil {
+		maps.Copy(errMap, hs.usernamePolicy.Validate(*partial.Username))
+	}
+
+	if len(errMap) > 0 {
 		return xerrors.UnprocessableEntityError(errMap)
 	}
 
@@ -69,6 +75,8 @@ func (hs *HandlerService) PatchProfileByUserId(c *fiber.Ctx) error {
 		return err
 	}
 
+	hs.publishProfile(c.Context(), uid)
+
 	return c.Status(http.StatusOK).JSON(partial)
 }
 
@@ -83,7 +91,7 @@ func (hs *HandlerService) CreateProfile(c *fiber.Ctx) error {
 		return xerrors.InvalidJSON()
 	}
 
-	if errMap := profile.Validate(); len(errMap) > 0 {
+	if errMap := hs.usernamePolicy.Validate(profile.Username); len(errMap) > 0 {
 		return xerrors.UnprocessableEntityError(errMap)
 	}
 
@@ -91,7 +99,20 @@ func (hs *HandlerService) CreateProfile(c *fiber.Ctx) error {
 	profile.CreatedAt = time.Now()
 	profile.UpdatedAt = time.Now()
 
-	if err := hs.storage.CreateProfile(c.Context(), profile); err != nil {
+	wid, err := uuid.NewRandom()
+	if err != nil {
+		return xerrors.InternalServerError()
+	}
+
+	// every user starts out with a personal workspace so rooms can be created right away
+	workspace := models.Workspace{
+		Id:        wid,
+		Name:      fmt.Sprintf("%s's workspace", profile.Username),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	if err := hs.storage.CreateProfile(c.Context(), profile, workspace); err != nil {
 		return err
 	}
 
@@ -113,10 +134,15 @@ func (hs *HandlerService) SearchProfiles(c *fiber.Ctx) error {
 		return xerrors.UnprocessableEntityError(errMap)
 	}
 
-	profiles, err := hs.storage.SearchProfiles(c.Context(), opts, uid)
+	wid, err := hs.currentWorkspaceId(c)
+	if err != nil {
+		return err
+	}
+
+	result, err := hs.storage.SearchProfiles(c.Context(), opts, uid, wid)
 	if err != nil {
 		return err
 	}
 
-	return c.Status(http.StatusOK).JSON(profiles)
+	return c.Status(http.StatusOK).JSON(result)
 }
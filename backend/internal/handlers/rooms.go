@@ -3,9 +3,11 @@ package handlers
 import (
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"go-chat/internal/models"
+	"go-chat/internal/protocol"
 	"go-chat/internal/types"
 	"go-chat/internal/xcontext"
 	"go-chat/internal/xerrors"
@@ -21,8 +23,9 @@ func (hs *HandlerService) CreateRoom(c *fiber.Ctx) error {
 	}
 
 	type request struct {
-		Members []uuid.UUID `json:"members"`
-		Name    string      `json:"name"`
+		Members    []uuid.UUID           `json:"members"`
+		Name       string                `json:"name"`
+		Visibility models.RoomVisibility `json:"visibility"`
 	}
 
 	var req request
@@ -36,20 +39,50 @@ func (hs *HandlerService) CreateRoom(c *fiber.Ctx) error {
 		})
 	}
 
+	wid, err := hs.currentWorkspaceId(c)
+	if err != nil {
+		return err
+	}
+
+	if req.Visibility == "" {
+		req.Visibility = models.RoomVisibilityPrivate
+	}
+
+	if req.Visibility != models.RoomVisibilityPrivate && req.Visibility != models.RoomVisibilityPublic {
+		return xerrors.UnprocessableEntityError(map[string]string{
+			"visibility": "visibility must be either private or public",
+		})
+	}
+
 	roomId, err := uuid.NewRandom()
 	if err != nil {
 		return xerrors.InternalServerError()
 	}
 
 	room := models.Room{
-		Id:        roomId,
-		Host:      uid,
-		Name:      req.Name,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		Id:            roomId,
+		Host:          &uid,
+		Name:          req.Name,
+		Kind:          models.RoomKindGroup,
+		Visibility:    req.Visibility,
+		PostingPolicy: models.PostingPolicyEveryone,
+		WorkspaceId:   &wid,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
 	}
 
-	result, err := hs.storage.CreateRoom(c.Context(), room, req.Members)
+	if err := hs.storage.CreateRoom(c.Context(), room); err != nil {
+		return err
+	}
+
+	hs.recordAudit(c.Context(), roomId, uid, models.AuditActionRoomCreated, nil, map[string]any{
+		"name":       room.Name,
+		"visibility": room.Visibility,
+	})
+
+	// members are invited rather than added so that nobody is placed in a room
+	// without consenting to it
+	result, err := hs.inviteUsers(c, room, uid, req.Members)
 	if err != nil {
 		return err
 	}
@@ -89,7 +122,14 @@ func (hs *HandlerService) GetMessagesByRoom(c *fiber.Ctx) error {
 	return c.Status(http.StatusOK).JSON(msgs)
 }
 
-func (s *HandlerService) AddUsersToRoom(c *fiber.Ctx) error {
+// AddUsersToRoom invites users to the room on behalf of a room admin. Users
+// only become members once they accept the invitation.
+func (hs *HandlerService) AddUsersToRoom(c *fiber.Ctx) error {
+	uid, err := xcontext.GetUserId(c)
+	if err != nil {
+		return err
+	}
+
 	type request struct {
 		UserIds []uuid.UUID `json:"user_ids"`
 	}
@@ -109,7 +149,20 @@ func (s *HandlerService) AddUsersToRoom(c *fiber.Ctx) error {
 		return xerrors.InvalidJSON()
 	}
 
-	result, err := s.storage.AddUsersToRoom(c.Context(), req.UserIds, rid)
+	if err := hs.requireRoomAdmin(c, rid, uid); err != nil {
+		return err
+	}
+
+	room, err := hs.getWritableRoom(c, rid)
+	if err != nil {
+		return err
+	}
+
+	if room.Kind == models.RoomKindDm {
+		return xerrors.BadRequestError("cannot add users to a dm")
+	}
+
+	result, err := hs.inviteUsers(c, room, uid, req.UserIds)
 	if err != nil {
 		return err
 	}
@@ -123,7 +176,21 @@ func (s *HandlerService) GetRoomsByUserId(c *fiber.Ctx) error {
 		return err
 	}
 
-	rooms, err := s.storage.GetRoomsByUserId(c.Context(), uid)
+	var opts types.GetRoomsOptions
+	if err := c.QueryParser(&opts); err != nil {
+		return xerrors.BadRequestError("failed to parse query parameters")
+	}
+
+	if errMap := opts.Validate(); len(errMap) > 0 {
+		return xerrors.UnprocessableEntityError(errMap)
+	}
+
+	wid, err := s.currentWorkspaceId(c)
+	if err != nil {
+		return err
+	}
+
+	rooms, err := s.storage.GetRoomsByUserId(c.Context(), opts, uid, wid)
 	if err != nil {
 		return err
 	}
@@ -144,10 +211,20 @@ func (hs *HandlerService) DeleteRoom(c *fiber.Ctx) error {
 		return xerrors.BadRequestError(fmt.Sprintf("invalid user id: %s", ridStr))
 	}
 
+	memberIds, err := hs.storage.GetRoomMemberIds(c.Context(), rid)
+	if err != nil {
+		return err
+	}
+
 	if err := hs.storage.DeleteRoomById(c.Context(), rid, uid); err != nil {
 		return err
 	}
 
+	hs.recordAudit(c.Context(), rid, uid, models.AuditActionRoomDeleted, nil, nil)
+	for _, memberId := range memberIds {
+		hs.syncPresencePeers(c.Context(), memberId)
+	}
+
 	return c.SendStatus(http.StatusNoContent)
 }
 
@@ -171,3 +248,341 @@ func (hs *HandlerService) GetProfilesByRoomId(c *fiber.Ctx) error {
 
 	return c.Status(http.StatusOK).JSON(profiles)
 }
+
+func (hs *HandlerService) DiscoverRooms(c *fiber.Ctx) error {
+	var opts types.DiscoverRoomsOptions
+	if err := c.QueryParser(&opts); err != nil {
+		return xerrors.BadRequestError("failed to parse query parameters")
+	}
+
+	if errMap := opts.Validate(); len(errMap) > 0 {
+		return xerrors.UnprocessableEntityError(errMap)
+	}
+
+	wid, err := hs.currentWorkspaceId(c)
+	if err != nil {
+		return err
+	}
+
+	rooms, err := hs.storage.DiscoverRooms(c.Context(), opts, wid)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(rooms)
+}
+
+func (hs *HandlerService) JoinRoom(c *fiber.Ctx) error {
+	uid, err := xcontext.GetUserId(c)
+	if err != nil {
+		return err
+	}
+
+	ridStr := c.Params("roomId")
+
+	rid, err := uuid.Parse(ridStr)
+	if err != nil {
+		return xerrors.BadRequestError(fmt.Sprintf("invalid room id: %s", ridStr))
+	}
+
+	if err := hs.storage.JoinPublicRoom(c.Context(), rid, uid); err != nil {
+		return err
+	}
+
+	hs.recordAudit(c.Context(), rid, uid, models.AuditActionMemberJoined, nil, map[string]any{
+		"via": "discovery",
+	})
+
+	hs.broadcastMemberJoined(c.Context(), rid, uid)
+
+	return c.SendStatus(http.StatusNoContent)
+}
+
+func (hs *HandlerService) PatchRoomById(c *fiber.Ctx) error {
+	uid, err := xcontext.GetUserId(c)
+	if err != nil {
+		return err
+	}
+
+	ridStr := c.Params("roomId")
+
+	rid, err := uuid.Parse(ridStr)
+	if err != nil {
+		return xerrors.BadRequestError(fmt.Sprintf("invalid room id: %s", ridStr))
+	}
+
+	var partial types.PartialRoom
+	if err := c.BodyParser(&partial); err != nil {
+		return xerrors.InvalidJSON()
+	}
+
+	if errMap := partial.Validate(); len(errMap) > 0 {
+		return xerrors.UnprocessableEntityError(errMap)
+	}
+
+	if err := hs.requireRoomAdmin(c, rid, uid); err != nil {
+		return err
+	}
+
+	existing, err := hs.getWritableRoom(c, rid)
+	if err != nil {
+		return err
+	}
+
+	if existing.Kind == models.RoomKindDm {
+		return xerrors.BadRequestError("cannot edit a dm")
+	}
+
+	actor, err := hs.storage.GetProfileByUserId(c.Context(), uid)
+	if err != nil {
+		return err
+	}
+
+	partial.UpdatedAt = time.Now()
+
+	room, err := hs.storage.PatchRoomById(c.Context(), partial, rid)
+	if err != nil {
+		return err
+	}
+
+	hs.recordAudit(c.Context(), rid, uid, models.AuditActionRoomUpdated, nil, partial)
+
+	message, err := hs.recordSystemMessage(c.Context(), rid, actor, strings.Join(partial.Changes(), ", "))
+	if err != nil {
+		return err
+	}
+
+	hs.broadcastToRoom(rid, protocol.RoomUpdatedEvent, roomUpdatedEvent{
+		Room:    room,
+		Message: message,
+	})
+
+	return c.Status(http.StatusOK).JSON(room)
+}
+
+func (hs *HandlerService) TransferRoomHost(c *fiber.Ctx) error {
+	uid, err := xcontext.GetUserId(c)
+	if err != nil {
+		return err
+	}
+
+	type request struct {
+		UserId uuid.UUID `json:"user_id"`
+	}
+
+	ridStr := c.Params("roomId")
+
+	rid, err := uuid.Parse(ridStr)
+	if err != nil {
+		return xerrors.BadRequestError(fmt.Sprintf("invalid room id: %s", ridStr))
+	}
+
+	var req request
+	if err := c.BodyParser(&req); err != nil {
+		return xerrors.InvalidJSON()
+	}
+
+	if req.UserId == uid {
+		return xerrors.BadRequestError("you are already the host of this room")
+	}
+
+	if _, err := hs.getWritableRoom(c, rid); err != nil {
+		return err
+	}
+
+	actor, err := hs.storage.GetProfileByUserId(c.Context(), uid)
+	if err != nil {
+		return err
+	}
+
+	newHost, err := hs.storage.GetProfileByUserId(c.Context(), req.UserId)
+	if err != nil {
+		return err
+	}
+
+	room, err := hs.storage.TransferRoomHost(c.Context(), rid, uid, req.UserId, time.Now())
+	if err != nil {
+		return err
+	}
+
+	hs.recordAudit(c.Context(), rid, uid, models.AuditActionHostTransferred, &req.UserId, nil)
+
+	message, err := hs.recordSystemMessage(c.Context(), rid, actor, fmt.Sprintf("transferred ownership to %s", newHost.Username))
+	if err != nil {
+		return err
+	}
+
+	hs.broadcastToRoom(rid, protocol.RoomUpdatedEvent, roomUpdatedEvent{
+		Room:    room,
+		Message: message,
+	})
+
+	return c.Status(http.StatusOK).JSON(room)
+}
+
+func (hs *HandlerService) ArchiveRoom(c *fiber.Ctx) error {
+	return hs.setRoomArchived(c, true)
+}
+
+func (hs *HandlerService) UnarchiveRoom(c *fiber.Ctx) error {
+	return hs.setRoomArchived(c, false)
+}
+
+func (hs *HandlerService) setRoomArchived(c *fiber.Ctx, archived bool) error {
+	uid, err := xcontext.GetUserId(c)
+	if err != nil {
+		return err
+	}
+
+	ridStr := c.Params("roomId")
+
+	rid, err := uuid.Parse(ridStr)
+	if err != nil {
+		return xerrors.BadRequestError(fmt.Sprintf("invalid room id: %s", ridStr))
+	}
+
+	if err := hs.requireRoomAdmin(c, rid, uid); err != nil {
+		return err
+	}
+
+	actor, err := hs.storage.GetProfileByUserId(c.Context(), uid)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	var (
+		archivedAt *time.Time
+		change     = "unarchived the room"
+		action     = models.AuditActionRoomUnarchived
+	)
+	if archived {
+		archivedAt = &now
+		change = "archived the room"
+		action = models.AuditActionRoomArchived
+	}
+
+	room, err := hs.storage.SetRoomArchivedAt(c.Context(), rid, archivedAt, now)
+	if err != nil {
+		return err
+	}
+
+	hs.recordAudit(c.Context(), rid, uid, action, nil, nil)
+
+	message, err := hs.recordSystemMessage(c.Context(), rid, actor, change)
+	if err != nil {
+		return err
+	}
+
+	hs.broadcastToRoom(rid, protocol.RoomUpdatedEvent, roomUpdatedEvent{
+		Room:    room,
+		Message: message,
+	})
+
+	return c.Status(http.StatusOK).JSON(room)
+}
+
+func (hs *HandlerService) PatchRoomSettings(c *fiber.Ctx) error {
+	uid, err := xcontext.GetUserId(c)
+	if err != nil {
+		return err
+	}
+
+	ridStr := c.Params("roomId")
+
+	rid, err := uuid.Parse(ridStr)
+	if err != nil {
+		return xerrors.BadRequestError(fmt.Sprintf("invalid room id: %s", ridStr))
+	}
+
+	var partial types.PartialRoomSettings
+	if err := c.BodyParser(&partial); err != nil {
+		return xerrors.InvalidJSON()
+	}
+
+	if errMap := partial.Validate(); len(errMap) > 0 {
+		return xerrors.UnprocessableEntityError(errMap)
+	}
+
+	settings, err := hs.storage.PatchRoomSettings(c.Context(), partial, rid, uid)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(settings)
+}
+
+func (hs *HandlerService) AddRoomAdmin(c *fiber.Ctx) error {
+	return hs.setRoomMemberRole(c, models.RoomRoleAdmin)
+}
+
+func (hs *HandlerService) RemoveRoomAdmin(c *fiber.Ctx) error {
+	return hs.setRoomMemberRole(c, models.RoomRoleMember)
+}
+
+func (hs *HandlerService) setRoomMemberRole(c *fiber.Ctx, role models.RoomRole) error {
+	uid, err := xcontext.GetUserId(c)
+	if err != nil {
+		return err
+	}
+
+	ridStr := c.Params("roomId")
+
+	rid, err := uuid.Parse(ridStr)
+	if err != nil {
+		return xerrors.BadRequestError(fmt.Sprintf("invalid room id: %s", ridStr))
+	}
+
+	midStr := c.Params("userId")
+
+	mid, err := uuid.Parse(midStr)
+	if err != nil {
+		return xerrors.BadRequestError(fmt.Sprintf("invalid user id: %s", midStr))
+	}
+
+	if _, err := hs.getWritableRoom(c, rid); err != nil {
+		return err
+	}
+
+	if err := hs.storage.SetRoomMemberRole(c.Context(), rid, mid, role, uid); err != nil {
+		return err
+	}
+
+	action := models.AuditActionAdminRemoved
+	if role == models.RoomRoleAdmin {
+		action = models.AuditActionAdminAdded
+	}
+
+	hs.recordAudit(c.Context(), rid, uid, action, &mid, nil)
+
+	return c.SendStatus(http.StatusNoContent)
+}
+
+// requireRoomAdmin returns a forbidden error unless the user is the host or an admin of the room
+func (hs *HandlerService) requireRoomAdmin(c *fiber.Ctx, roomId uuid.UUID, userId uuid.UUID) error {
+	isAdmin, err := hs.storage.CheckUserIsRoomAdmin(c.Context(), roomId, userId)
+	if err != nil {
+		return err
+	}
+
+	if !isAdmin {
+		return xerrors.ForbiddenError("only the host or an admin can perform this action")
+	}
+
+	return nil
+}
+
+// getWritableRoom returns the room unless it is archived and therefore read-only
+func (hs *HandlerService) getWritableRoom(c *fiber.Ctx, roomId uuid.UUID) (models.Room, error) {
+	room, err := hs.storage.GetRoomById(c.Context(), roomId)
+	if err != nil {
+		return models.Room{}, err
+	}
+
+	if room.ArchivedAt != nil {
+		return models.Room{}, xerrors.RoomArchivedError()
+	}
+
+	return room, nil
+}
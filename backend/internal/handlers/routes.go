@@ -24,6 +24,9 @@ func (hs *HandlerService) RegisterRoutes(app *fiber.App) {
 			return c.SendStatus(http.StatusOK)
 		})
 
+		// avatars are public so they can be loaded directly by image tags without a token
+		api.Get("/avatars/:userId", hs.GetAvatar)
+
 		api.Use(swagger.New(swagger.Config{
 			BasePath: "/api/",
 			FilePath: "./api/swagger.json",
@@ -35,6 +38,7 @@ func (hs *HandlerService) RegisterRoutes(app *fiber.App) {
 		api.Use(idempotency.New(idempotency.Config{
 			Storage: hs.fiberStorage,
 		}))
+		api.Use(middleware.SetUserId())
 		api.Use(middleware.SetCacheHeaders())
 		api.Use(cache.New(cache.Config{
 			KeyGenerator: middleware.CacheKeyGenerator,
@@ -43,25 +47,91 @@ func (hs *HandlerService) RegisterRoutes(app *fiber.App) {
 			CacheControl: true,
 			Expiration:   constants.CacheExpiration,
 		}))
-		api.Use(middleware.SetUserId())
 
 		api.Route("/rooms", func(rooms fiber.Router) {
 			rooms.Get("/", hs.GetRoomsByUserId)
 			rooms.Post("/", hs.CreateRoom)
+			rooms.Get("/discover", hs.DiscoverRooms)
+			rooms.Patch("/:roomId", hs.PatchRoomById)
 			rooms.Delete("/:roomId", hs.DeleteRoom)
 			rooms.Get("/:roomId/messages", hs.GetMessagesByRoom)
 			rooms.Post("/:roomId/users", hs.AddUsersToRoom)
 			rooms.Get("/:roomId/profiles", hs.GetProfilesByRoomId)
+			rooms.Post("/:roomId/join", hs.JoinRoom)
+			rooms.Patch("/:roomId/settings", hs.PatchRoomSettings)
+			rooms.Post("/:roomId/transfer", hs.TransferRoomHost)
+			rooms.Post("/:roomId/archive", hs.ArchiveRoom)
+			rooms.Post("/:roomId/unarchive", hs.UnarchiveRoom)
+			rooms.Put("/:roomId/admins/:userId", hs.AddRoomAdmin)
+			rooms.Delete("/:roomId/admins/:userId", hs.RemoveRoomAdmin)
+			rooms.Get("/:roomId/invites", hs.GetInvitesByRoomId)
+			rooms.Post("/:roomId/invites", hs.CreateInvite)
+			rooms.Delete("/:roomId/invites/:code", hs.RevokeInvite)
+			rooms.Post("/:roomId/invitations", hs.InviteUsersToRoom)
+			rooms.Get("/:roomId/audit", hs.GetAuditLogByRoomId)
+			rooms.Get("/:roomId/bans", hs.GetBansByRoomId)
+			rooms.Put("/:roomId/bans/:userId", hs.BanUserFromRoom)
+			rooms.Delete("/:roomId/bans/:userId", hs.UnbanUserFromRoom)
+			rooms.Get("/:roomId/mutes", hs.GetMutesByRoomId)
+			rooms.Put("/:roomId/mutes/:userId", hs.MuteUserInRoom)
+			rooms.Delete("/:roomId/mutes/:userId", hs.UnmuteUserInRoom)
+		})
+
+		api.Route("/workspaces", func(workspaces fiber.Router) {
+			workspaces.Get("/", hs.GetWorkspacesByUserId)
+			workspaces.Post("/", hs.CreateWorkspace)
+			workspaces.Patch("/:workspaceId", hs.PatchWorkspace)
+			workspaces.Get("/:workspaceId/members", hs.GetProfilesByWorkspaceId)
+			workspaces.Post("/:workspaceId/members", hs.AddUsersToWorkspace)
+			workspaces.Delete("/:workspaceId/members/:userId", hs.RemoveUserFromWorkspace)
 		})
 
 		api.Route("/profiles", func(profiles fiber.Router) {
 			profiles.Get("/", hs.GetProfileByUserId)
 			profiles.Patch("/", hs.PatchProfileByUserId)
 			profiles.Post("/", hs.CreateProfile)
+			profiles.Delete("/", hs.DeleteProfile)
+			profiles.Get("/export", hs.ExportProfile)
+			profiles.Put("/avatar", hs.UploadAvatar)
+			profiles.Delete("/avatar", hs.DeleteAvatar)
 			profiles.Get("/search", hs.SearchProfiles)
 			profiles.Get("/:profileId", hs.GetForeignProfileByUserId)
 		})
 
+		api.Route("/preferences", func(preferences fiber.Router) {
+			preferences.Get("/", hs.GetPreferences)
+			preferences.Put("/", hs.PutPreferences)
+		})
+
+		api.Route("/dms", func(dms fiber.Router) {
+			dms.Post("/:userId", hs.GetOrCreateDm)
+		})
+
+		api.Route("/invites", func(invites fiber.Router) {
+			invites.Post("/:code", hs.RedeemInvite)
+		})
+
+		api.Route("/invitations", func(invitations fiber.Router) {
+			invitations.Get("/", hs.GetPendingInvitations)
+			invitations.Post("/:invitationId/accept", hs.AcceptInvitation)
+			invitations.Post("/:invitationId/decline", hs.DeclineInvitation)
+		})
+
+		api.Route("/contacts", func(contacts fiber.Router) {
+			contacts.Get("/", hs.GetContacts)
+			contacts.Delete("/:userId", hs.RemoveContact)
+			contacts.Get("/requests", hs.GetContactRequests)
+			contacts.Put("/requests/:userId", hs.SendContactRequest)
+			contacts.Post("/requests/:userId/accept", hs.AcceptContactRequest)
+			contacts.Delete("/requests/:userId", hs.DeleteContactRequest)
+		})
+
+		api.Route("/blocks", func(blocks fiber.Router) {
+			blocks.Get("/", hs.GetBlockedProfiles)
+			blocks.Put("/:userId", hs.BlockUser)
+			blocks.Delete("/:userId", hs.UnblockUser)
+		})
+
 		api.Route("/messages", func(messages fiber.Router) {
 			messages.Delete("/:messageId", hs.DeleteMessageById)
 		})
@@ -2,14 +2,19 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"log/slog"
+	"sync"
 
+	"go-chat/internal/protocol"
 	"go-chat/internal/utils"
+	"go-chat/internal/xerrors"
 
 	"github.com/aaronkim218/eventsocket"
 
 	"github.com/gofiber/contrib/websocket"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 func (hs *HandlerService) HandleUserConnection(conn *websocket.Conn) {
@@ -23,27 +28,53 @@ func (hs *HandlerService) HandleUserConnection(conn *websocket.Conn) {
 		}
 	}()
 
-	_, tokenBytes, err := conn.ReadMessage()
+	_, helloBytes, err := conn.ReadMessage()
 	if err != nil {
-		hs.logger.Error("Failed to read JWT", slog.String("err", err.Error()))
+		hs.logger.Error("Failed to read handshake", slog.String("err", err.Error()))
+		return
+	}
+
+	hello, protocolErr := protocol.ParseHello(helloBytes)
+	if protocolErr != nil {
+		hs.sendHandshakeError(conn, protocolErr)
+		return
+	}
+
+	version, ok := protocol.Negotiate(hello.Versions)
+	if !ok {
+		hs.sendHandshakeError(conn, &protocol.Error{
+			Code:    protocol.ErrorCodeUnsupportedVersion,
+			Event:   protocol.HelloEvent,
+			Message: "none of the offered protocol versions are supported",
+		})
 		return
 	}
 
 	token, err := jwt.Parse(
-		string(tokenBytes),
+		hello.Token,
 		hs.keyFunc,
 	)
 	if err != nil {
 		hs.logger.Error("Failed to parse token",
 			slog.String("err", err.Error()),
-			slog.String("msg", string(tokenBytes)),
+			slog.String("msg", hello.Token),
 		)
+		hs.sendHandshakeError(conn, &protocol.Error{
+			Code:    protocol.ErrorCodeUnauthorized,
+			Event:   protocol.HelloEvent,
+			Message: "invalid token",
+		})
 		return
 	}
 
 	uid, err := utils.GetUserIdFromToken(token)
 	if err != nil {
 		hs.logger.Error("Failed to get user id from token", slog.String("err", err.Error()))
+		hs.sendHandshakeError(conn, &protocol.Error{
+			Code:    protocol.ErrorCodeUnauthorized,
+			Event:   protocol.HelloEvent,
+			Message: "invalid token",
+		})
 		return
 	}
 
@@ -53,24 +84,160 @@ func (hs *HandlerService) HandleUserConnection(conn *websocket.Conn) {
 			slog.String("err", err.Error()),
 			slog.String("userId", uid.String()),
 		)
+		if xerrors.IsNotFound(err) {
+			hs.sendHandshakeError(conn, &protocol.Error{
+				Code:    protocol.ErrorCodeProfileNotFound,
+				Event:   protocol.HelloEvent,
+				Message: "create a profile before connecting",
+			})
+		} else {
+			hs.sendHandshakeError(conn, &protocol.Error{
+				Code:    protocol.ErrorCodeInternal,
+				Event:   protocol.HelloEvent,
+				Message: "failed to load profile",
+			})
+		}
 		return
 	}
 
+	protocolConn := protocol.NewConn(&protocol.ConnConfig{
+		Socket:  conn,
+		Version: version,
+		Logger:  hs.logger,
+		OnFrame: func() {
+			hs.pluginsContainer.Presence.Touch(uid.String())
+		},
+	})
+
 	client, err := hs.eventsocket.CreateClient(&eventsocket.CreateClientConfig{
 		ID:   uid.String(),
-		Conn: conn,
+		Conn: protocolConn,
 	})
 	if err != nil {
 		hs.logger.Error("Failed to create client",
 			slog.String("err", err.Error()),
 			slog.String("userId", uid.String()),
 		)
+		if errors.Is(err, eventsocket.ErrClientExists) {
+			hs.sendHandshakeError(conn, &protocol.Error{
+				Code:    protocol.ErrorCodeAlreadyConnected,
+				Event:   protocol.HelloEvent,
+				Message: "already connected from another session",
+			})
+		} else {
+			hs.sendHandshakeError(conn, &protocol.Error{
+				Code:    protocol.ErrorCodeInternal,
+				Event:   protocol.HelloEvent,
+				Message: "failed to register connection",
+			})
+		}
 		return
 	}
 
+	connectionId := uuid.New()
+
+	// the client is registered before WELCOME so a refused connection is never
+	// told the handshake succeeded, and legacy clients do not expect WELCOME
+	if !hello.Legacy {
+		welcome, err := protocol.NewFrame(protocol.WelcomeEvent, protocol.Welcome{
+			Version:      version,
+			ConnectionId: connectionId.String(),
+		})
+		if err == nil {
+			err = protocolConn.WriteJSON(welcome)
+		}
+
+		if err != nil {
+			hs.logger.Error("Failed to send welcome",
+				slog.String("err", err.Error()),
+				slog.String("userId", uid.String()),
+			)
+
+			// closing the socket ends the client's read loop, which removes it
+			success = true
+			_ = protocolConn.Close()
+			<-client.Done()
+			return
+		}
+	}
+
 	hs.pluginsContainer.RegisterClient(client, profile)
+	hs.connections.add(uid, connectionId, protocolConn)
 
 	success = true
 
 	<-client.Done()
+
+	hs.connections.remove(uid, protocolConn)
+}
+
+// connections holds the protocol connection of every connected user. Closing
+// it disconnects the user without calling eventsocket.RemoveClient, which can
+// leave the eventsocket lock held.
+type connections struct {
+	conns map[uuid.UUID]connection
+	mu    sync.Mutex
+}
+
+// connection is a user's socket and the id it was given in WELCOME, which the
+// client sends back on http requests made from the same session
+type connection struct {
+	id   uuid.UUID
+	conn *protocol.Conn
+}
+
+func newConnections() *connections {
+	return &connections{
+		conns: make(map[uuid.UUID]connection),
+	}
+}
+
+func (cs *connections) add(userId uuid.UUID, connectionId uuid.UUID, conn *protocol.Conn) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	cs.conns[userId] = connection{id: connectionId, conn: conn}
+}
+
+// remove forgets the user's connection unless it has already been replaced
+func (cs *connections) remove(userId uuid.UUID, conn *protocol.Conn) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if cs.conns[userId].conn == conn {
+		delete(cs.conns, userId)
+	}
+}
+
+func (cs *connections) get(userId uuid.UUID) (*protocol.Conn, bool) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	connection, exists := cs.conns[userId]
+	return connection.conn, exists
+}
+
+// isOrigin reports whether the user's connection is the one with the given id
+func (cs *connections) isOrigin(userId uuid.UUID, connectionId string) bool {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	connection, exists := cs.conns[userId]
+	return exists && connection.id.String() == connectionId
+}
+
+// sendHandshakeError answers a failed handshake with an ERROR frame before the
+// connection is closed
+func (hs *HandlerService) sendHandshakeError(conn *websocket.Conn, protocolErr *protocol.Error) {
+	frame, err := protocol.NewFrame(protocol.ErrorEvent, protocolErr)
+	if err == nil {
+		err = conn.WriteJSON(frame)
+	}
+
+	if err != nil {
+		hs.logger.Error("Failed to send handshake error",
+			slog.String("err", err.Error()),
+			slog.String("code", string(protocolErr.Code)),
+		)
+	}
 }
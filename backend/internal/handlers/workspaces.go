@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"go-chat/internal/constants"
+	"go-chat/internal/models"
+	"go-chat/internal/types"
+	"go-chat/internal/xcontext"
+	"go-chat/internal/xerrors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+func (hs *HandlerService) CreateWorkspace(c *fiber.Ctx) error {
+	uid, err := xcontext.GetUserId(c)
+	if err != nil {
+		return err
+	}
+
+	var workspace models.Workspace
+	if err := c.BodyParser(&workspace); err != nil {
+		return xerrors.InvalidJSON()
+	}
+
+	if errMap := workspace.Validate(); len(errMap) > 0 {
+		return xerrors.UnprocessableEntityError(errMap)
+	}
+
+	wid, err := uuid.NewRandom()
+	if err != nil {
+		return xerrors.InternalServerError()
+	}
+
+	workspace.Id = wid
+	workspace.CreatedAt = time.Now()
+	workspace.UpdatedAt = time.Now()
+
+	if err := hs.storage.CreateWorkspace(c.Context(), workspace, uid); err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusCreated).JSON(types.UserWorkspace{
+		Workspace: workspace,
+		Role:      models.WorkspaceRoleOwner,
+	})
+}
+
+func (hs *HandlerService) GetWorkspacesByUserId(c *fiber.Ctx) error {
+	uid, err := xcontext.GetUserId(c)
+	if err != nil {
+		return err
+	}
+
+	workspaces, err := hs.storage.GetWorkspacesByUserId(c.Context(), uid)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(workspaces)
+}
+
+func (hs *HandlerService) PatchWorkspace(c *fiber.Ctx) error {
+	uid, err := xcontext.GetUserId(c)
+	if err != nil {
+		return err
+	}
+
+	widStr := c.Params("workspaceId")
+
+	wid, err := uuid.Parse(widStr)
+	if err != nil {
+		return xerrors.BadRequestError(fmt.Sprintf("invalid workspace id: %s", widStr))
+	}
+
+	var partial types.PartialWorkspace
+	if err := c.BodyParser(&partial); err != nil {
+		return xerrors.InvalidJSON()
+	}
+
+	if errMap := partial.Validate(); len(errMap) > 0 {
+		return xerrors.UnprocessableEntityError(errMap)
+	}
+
+	partial.UpdatedAt = time.Now()
+
+	workspace, err := hs.storage.PatchWorkspace(c.Context(), partial, wid, uid)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(workspace)
+}
+
+func (hs *HandlerService) GetProfilesByWorkspaceId(c *fiber.Ctx) error {
+	uid, err := xcontext.GetUserId(c)
+	if err != nil {
+		return err
+	}
+
+	widStr := c.Params("workspaceId")
+
+	wid, err := uuid.Parse(widStr)
+	if err != nil {
+		return xerrors.BadRequestError(fmt.Sprintf("invalid workspace id: %s", widStr))
+	}
+
+	inWorkspace, err := hs.storage.CheckUserInWorkspace(c.Context(), wid, uid)
+	if err != nil {
+		return err
+	}
+
+	if !inWorkspace {
+		return xerrors.NotFoundError("workspace", map[string]string{
+			"id": wid.String(),
+		})
+	}
+
+	profiles, err := hs.storage.GetProfilesByWorkspaceId(c.Context(), wid)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(profiles)
+}
+
+func (hs *HandlerService) AddUsersToWorkspace(c *fiber.Ctx) error {
+	uid, err := xcontext.GetUserId(c)
+	if err != nil {
+		return err
+	}
+
+	type request struct {
+		UserIds []uuid.UUID `json:"user_ids"`
+	}
+
+	widStr := c.Params("workspaceId")
+
+	wid, err := uuid.Parse(widStr)
+	if err != nil {
+		return xerrors.BadRequestError(fmt.Sprintf("invalid workspace id: %s", widStr))
+	}
+
+	var req request
+	if err := c.BodyParser(&req); err != nil {
+		return xerrors.InvalidJSON()
+	}
+
+	result, err := hs.storage.AddUsersToWorkspace(c.Context(), req.UserIds, wid, uid)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(result)
+}
+
+func (hs *HandlerService) RemoveUserFromWorkspace(c *fiber.Ctx) error {
+	uid, err := xcontext.GetUserId(c)
+	if err != nil {
+		return err
+	}
+
+	widStr := c.Params("workspaceId")
+
+	wid, err := uuid.Parse(widStr)
+	if err != nil {
+		return xerrors.BadRequestError(fmt.Sprintf("invalid workspace id: %s", widStr))
+	}
+
+	midStr := c.Params("userId")
+
+	mid, err := uuid.Parse(midStr)
+	if err != nil {
+		return xerrors.BadRequestError(fmt.Sprintf("invalid user id: %s", midStr))
+	}
+
+	if err := hs.storage.RemoveUserFromWorkspace(c.Context(), wid, mid, uid); err != nil {
+		return err
+	}
+
+	hs.syncPresencePeers(c.Context(), mid)
+
+	return c.SendStatus(http.StatusNoContent)
+}
+
+// currentWorkspaceId resolves the caller's current workspace from the workspace header, falling back to the first
+// workspace they joined. Only the handlers that are scoped to a workspace resolve it, and the result is kept in the
+// request locals so it is looked up at most once per request.
+func (hs *HandlerService) currentWorkspaceId(c *fiber.Ctx) (uuid.UUID, error) {
+	if wid, err := xcontext.GetWorkspaceId(c); err == nil {
+		return wid, nil
+	}
+
+	uid, err := xcontext.GetUserId(c)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	if widStr := c.Get(constants.HeaderKeyWorkspaceId); widStr != "" {
+		wid, err := uuid.Parse(widStr)
+		if err != nil {
+			return uuid.UUID{}, xerrors.BadRequestError(fmt.Sprintf("invalid workspace id: %s", widStr))
+		}
+
+		inWorkspace, err := hs.storage.CheckUserInWorkspace(c.Context(), wid, uid)
+		if err != nil {
+			return uuid.UUID{}, err
+		}
+
+		if !inWorkspace {
+			return uuid.UUID{}, xerrors.ForbiddenError("not a member of the selected workspace")
+		}
+
+		xcontext.SetWorkspaceId(c, wid)
+
+		return wid, nil
+	}
+
+	wid, ok, err := hs.storage.GetDefaultWorkspaceId(c.Context(), uid)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	if !ok {
+		return uuid.UUID{}, xerrors.BadRequestError("a workspace must be selected")
+	}
+
+	xcontext.SetWorkspaceId(c, wid)
+
+	return wid, nil
+}
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"go-chat/internal/constants"
+	"go-chat/internal/xcontext"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -9,7 +10,7 @@ import (
 func SetCacheHeaders() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		if _, ok := constants.CacheableRoutes[c.Path()]; ok {
-			c.Set(constants.HeaderKeyVary, constants.HeaderValueAuthorization)
+			c.Set(constants.HeaderKeyVary, constants.HeaderValueAuthorization+", "+constants.HeaderKeyWorkspaceId)
 		}
 
 		return c.Next()
@@ -19,7 +20,10 @@ func SetCacheHeaders() fiber.Handler {
 func CacheKeyGenerator(c *fiber.Ctx) string {
 	switch c.Path() {
 	case constants.SearchProfiles:
-		return c.OriginalURL()
+		// search results depend on the caller, their blocks and contacts, and the selected workspace, which falls
+		// back to the caller's default when the header is absent
+		uid, _ := xcontext.GetUserId(c)
+		return uid.String() + ":" + c.Get(constants.HeaderKeyWorkspaceId) + ":" + c.OriginalURL()
 	default:
 		return c.Path()
 	}
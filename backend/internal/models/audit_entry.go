@@ -0,0 +1,40 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AuditAction string
+
+const (
+	AuditActionRoomCreated     AuditAction = "room_created"
+	AuditActionRoomUpdated     AuditAction = "room_updated"
+	AuditActionRoomDeleted     AuditAction = "room_deleted"
+	AuditActionRoomArchived    AuditAction = "room_archived"
+	AuditActionRoomUnarchived  AuditAction = "room_unarchived"
+	AuditActionHostTransferred AuditAction = "host_transferred"
+	AuditActionMemberAdded     AuditAction = "member_added"
+	AuditActionMemberJoined    AuditAction = "member_joined"
+	AuditActionAdminAdded      AuditAction = "admin_added"
+	AuditActionAdminRemoved    AuditAction = "admin_removed"
+	AuditActionMemberBanned    AuditAction = "member_banned"
+	AuditActionMemberUnbanned  AuditAction = "member_unbanned"
+	AuditActionMemberMuted     AuditAction = "member_muted"
+	AuditActionMemberUnmuted   AuditAction = "member_unmuted"
+	AuditActionInviteCreated   AuditAction = "invite_created"
+	AuditActionInviteRevoked   AuditAction = "invite_revoked"
+	AuditActionMessageDeleted  AuditAction = "message_deleted"
+)
+
+type AuditEntry struct {
+	Id        uuid.UUID       `json:"id" db:"id"`
+	RoomId    uuid.UUID       `json:"room_id" db:"room_id"`
+	Actor     *uuid.UUID      `json:"actor" db:"actor"`
+	Action    AuditAction     `json:"action" db:"action"`
+	Target    *uuid.UUID      `json:"target" db:"target"`
+	Details   json.RawMessage `json:"details" db:"details"`
+	CreatedAt time.Time       `json:"created_at" db:"created_at"`
+}
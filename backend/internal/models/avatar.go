@@ -0,0 +1,14 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Avatar struct {
+	UserId    uuid.UUID `db:"user_id"`
+	Size      int       `db:"size"`
+	Data      []byte    `db:"data"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Block struct {
+	Blocker   uuid.UUID `json:"blocker" db:"blocker"`
+	Blocked   uuid.UUID `json:"blocked" db:"blocked"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
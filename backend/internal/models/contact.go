@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ContactStatus string
+
+const (
+	ContactStatusPending  ContactStatus = "pending"
+	ContactStatusAccepted ContactStatus = "accepted"
+	ContactStatusDeclined ContactStatus = "declined"
+)
+
+type Contact struct {
+	Requester  uuid.UUID     `json:"requester" db:"requester"`
+	Recipient  uuid.UUID     `json:"recipient" db:"recipient"`
+	Status     ContactStatus `json:"status" db:"status"`
+	CreatedAt  time.Time     `json:"created_at" db:"created_at"`
+	AcceptedAt *time.Time    `json:"accepted_at" db:"accepted_at"`
+	DeclinedAt *time.Time    `json:"declined_at" db:"declined_at"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type InvitationStatus string
+
+const (
+	InvitationStatusPending  InvitationStatus = "pending"
+	InvitationStatusAccepted InvitationStatus = "accepted"
+	InvitationStatusDeclined InvitationStatus = "declined"
+)
+
+type Invitation struct {
+	Id        uuid.UUID        `json:"id" db:"id"`
+	RoomId    uuid.UUID        `json:"room_id" db:"room_id"`
+	Inviter   uuid.UUID        `json:"inviter" db:"inviter"`
+	Invitee   uuid.UUID        `json:"invitee" db:"invitee"`
+	Status    InvitationStatus `json:"status" db:"status"`
+	CreatedAt time.Time        `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at" db:"updated_at"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Invite struct {
+	Code      string     `json:"code" db:"code"`
+	RoomId    uuid.UUID  `json:"room_id" db:"room_id"`
+	CreatedBy uuid.UUID  `json:"created_by" db:"created_by"`
+	MaxUses   *int       `json:"max_uses" db:"max_uses"`
+	Uses      int        `json:"uses" db:"uses"`
+	ExpiresAt *time.Time `json:"expires_at" db:"expires_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
@@ -6,11 +6,19 @@ import (
 	"github.com/google/uuid"
 )
 
+type MessageKind string
+
+const (
+	MessageKindUser   MessageKind = "user"
+	MessageKindSystem MessageKind = "system"
+)
+
 type Message struct {
-	Id        uuid.UUID `json:"id" db:"id"`
-	RoomId    uuid.UUID `json:"room_id" db:"room_id"`
-	Author    uuid.UUID `json:"author" db:"author"`
-	Content   string    `json:"content" db:"content"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	Id        uuid.UUID   `json:"id" db:"id"`
+	RoomId    uuid.UUID   `json:"room_id" db:"room_id"`
+	Author    *uuid.UUID  `json:"author" db:"author"`
+	Content   string      `json:"content" db:"content"`
+	Kind      MessageKind `json:"kind" db:"kind"`
+	CreatedAt time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at" db:"updated_at"`
 }
@@ -0,0 +1,14 @@
+package models
+
+import "fmt"
+
+// Preferences are the client settings synced across the user's devices. Version increases with every update and
+// backs the ETag used for optimistic concurrency.
+type Preferences struct {
+	Values  map[string]any `json:"values" db:"preferences"`
+	Version int64          `json:"version" db:"preferences_version"`
+}
+
+func (p Preferences) ETag() string {
+	return fmt.Sprintf(`"%d"`, p.Version)
+}
@@ -0,0 +1,11 @@
+package models
+
+type PresenceStatus string
+
+const (
+	PresenceStatusOnline    PresenceStatus = "online"
+	PresenceStatusAway      PresenceStatus = "away"
+	PresenceStatusDnd       PresenceStatus = "dnd"
+	PresenceStatusInvisible PresenceStatus = "invisible"
+	PresenceStatusOffline   PresenceStatus = "offline"
+)
@@ -0,0 +1,11 @@
+package models
+
+// ProfileDetails are the profile fields too heavy to embed in every compact profile, such as the ones attached to
+// presence and typing broadcasts
+type ProfileDetails struct {
+	Bio      string   `json:"bio" db:"bio"`
+	Pronouns string   `json:"pronouns" db:"pronouns"`
+	Timezone string   `json:"timezone" db:"timezone"`
+	Locale   string   `json:"locale" db:"locale"`
+	Links    []string `json:"links" db:"links"`
+}
@@ -6,10 +6,47 @@ import (
 	"github.com/google/uuid"
 )
 
+type RoomKind string
+
+const (
+	RoomKindGroup RoomKind = "group"
+	RoomKindDm    RoomKind = "dm"
+)
+
+type RoomVisibility string
+
+const (
+	RoomVisibilityPrivate RoomVisibility = "private"
+	RoomVisibilityPublic  RoomVisibility = "public"
+)
+
+type RoomRole string
+
+const (
+	RoomRoleMember RoomRole = "member"
+	RoomRoleAdmin  RoomRole = "admin"
+)
+
+type PostingPolicy string
+
+const (
+	PostingPolicyEveryone PostingPolicy = "everyone"
+	PostingPolicyAdmins   PostingPolicy = "admins"
+)
+
 type Room struct {
-	Id        uuid.UUID `json:"id" db:"id"`
-	Name      string    `json:"name" db:"name"`
-	Host      uuid.UUID `json:"host" db:"host"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	Id              uuid.UUID      `json:"id" db:"id"`
+	Name            string         `json:"name" db:"name"`
+	Host            *uuid.UUID     `json:"host" db:"host"`
+	Kind            RoomKind       `json:"kind" db:"kind"`
+	Visibility      RoomVisibility `json:"visibility" db:"visibility"`
+	Topic           string         `json:"topic" db:"topic"`
+	Description     string         `json:"description" db:"description"`
+	Avatar          string         `json:"avatar" db:"avatar"`
+	ArchivedAt      *time.Time     `json:"archived_at" db:"archived_at"`
+	PostingPolicy   PostingPolicy  `json:"posting_policy" db:"posting_policy"`
+	SlowModeSeconds int            `json:"slow_mode_seconds" db:"slow_mode_seconds"`
+	WorkspaceId     *uuid.UUID     `json:"workspace_id" db:"workspace_id"`
+	CreatedAt       time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at" db:"updated_at"`
 }
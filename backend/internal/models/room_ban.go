@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type RoomBan struct {
+	RoomId    uuid.UUID  `json:"room_id" db:"room_id"`
+	UserId    uuid.UUID  `json:"user_id" db:"user_id"`
+	BannedBy  *uuid.UUID `json:"banned_by" db:"banned_by"`
+	Reason    string     `json:"reason" db:"reason"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
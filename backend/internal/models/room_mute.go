@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type RoomMute struct {
+	RoomId    uuid.UUID  `json:"room_id" db:"room_id"`
+	UserId    uuid.UUID  `json:"user_id" db:"user_id"`
+	MutedBy   *uuid.UUID `json:"muted_by" db:"muted_by"`
+	ExpiresAt time.Time  `json:"expires_at" db:"expires_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
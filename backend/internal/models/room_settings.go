@@ -0,0 +1,8 @@
+package models
+
+type RoomSettings struct {
+	Favorite bool `json:"favorite" db:"favorite"`
+	Muted    bool `json:"muted" db:"muted"`
+	Hidden   bool `json:"hidden" db:"hidden"`
+	Position *int `json:"position" db:"position"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"go-chat/internal/constants"
+
+	"github.com/google/uuid"
+)
+
+type WorkspaceRole string
+
+const (
+	WorkspaceRoleOwner  WorkspaceRole = "owner"
+	WorkspaceRoleMember WorkspaceRole = "member"
+)
+
+type Workspace struct {
+	Id              uuid.UUID `json:"id" db:"id"`
+	Name            string    `json:"name" db:"name"`
+	DmsContactsOnly bool      `json:"dms_contacts_only" db:"dms_contacts_only"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
+}
+
+func (w *Workspace) Validate() map[string]string {
+	errMap := make(map[string]string)
+
+	if w.Name == "" || len(w.Name) > constants.MaxWorkspaceNameLength {
+		errMap["name"] = fmt.Sprintf("name length must be between 1 and %d", constants.MaxWorkspaceNameLength)
+	}
+
+	return errMap
+}
@@ -6,11 +6,13 @@ import (
 	"go-chat/internal/constants"
 	"go-chat/internal/models"
 	"go-chat/internal/storage"
+	"go-chat/internal/usernames"
 
 	"github.com/aaronkim218/eventsocket"
 )
 
 type Container struct {
+	Profiles       *ProfileRegistry
 	Presence       *Presence
 	RoomManagement *RoomManagement
 	UserMessage    *UserMessagePlugin
@@ -18,16 +20,27 @@ type Container struct {
 }
 
 type ContainerConfig struct {
-	Eventsocket *eventsocket.Eventsocket
-	Storage     storage.Storage
-	Logger      *slog.Logger
+	Eventsocket    *eventsocket.Eventsocket
+	Storage        storage.Storage
+	Logger         *slog.Logger
+	UsernamePolicy *usernames.Policy
 }
 
 func NewContainer(cfg *ContainerConfig) *Container {
+	profiles := NewProfileRegistry(&ProfileRegistryConfig{
+		Eventsocket: cfg.Eventsocket,
+		Logger:      cfg.Logger,
+	})
+
 	return &Container{
+		Profiles: profiles,
 		Presence: NewEventsocketPresencePlugin(&PresenceConfig{
-			Eventsocket: cfg.Eventsocket,
-			Logger:      cfg.Logger,
+			Eventsocket:   cfg.Eventsocket,
+			Storage:       cfg.Storage,
+			Logger:        cfg.Logger,
+			Profiles:      profiles,
+			IdleTimeout:   constants.PresenceIdleTimeout,
+			SweepInterval: constants.PresenceSweepInterval,
 		}),
 		RoomManagement: NewRoomManagementPlugin(&RoomManagementConfig{
 			Eventsocket: cfg.Eventsocket,
@@ -38,10 +51,13 @@ func NewContainer(cfg *ContainerConfig) *Container {
 			Eventsocket: cfg.Eventsocket,
 			Storage:     cfg.Storage,
 			Logger:      cfg.Logger,
+			Profiles:    profiles,
+			Usernames:   cfg.UsernamePolicy,
 		}),
 		TypingStatus: NewEventsocketTypingStatusPlugin(&TypingStatusPluginConfig{
 			Eventsocket:     cfg.Eventsocket,
 			Logger:          cfg.Logger,
+			Profiles:        profiles,
 			Timeout:         constants.TypingStatusTimeout,
 			CleanupInterval: constants.TypingStatusCleanupInterval,
 		}),
@@ -49,8 +65,25 @@ func NewContainer(cfg *ContainerConfig) *Container {
 }
 
 func (c *Container) RegisterClient(client *eventsocket.Client, profile models.Profile) {
-	c.Presence.RegisterClient(client.ID(), profile)
+	c.Profiles.RegisterClient(client, profile)
+	c.Presence.RegisterClient(client, profile)
 	c.RoomManagement.RegisterClient(client, profile)
 	c.UserMessage.RegisterClient(client, profile)
 	c.TypingStatus.RegisterClient(client, profile)
 }
+
+// UpdateProfile replaces the profile the plugins use for the user's connected
+// client and tells the rooms they are active in about the change
+func (c *Container) UpdateProfile(profile models.Profile) {
+	if !c.Profiles.Update(profile) {
+		return
+	}
+
+	c.Presence.PublishProfile(profile)
+}
+
+// Stop ends the background work of the plugins
+func (c *Container) Stop() {
+	c.Presence.Stop()
+	c.TypingStatus.Stop()
+}
@@ -1,17 +1,18 @@
 package plugins
 
 import (
+	"context"
 	"encoding/json"
 	"log/slog"
 	"sync"
+	"time"
 
 	"go-chat/internal/models"
+	"go-chat/internal/protocol"
+	"go-chat/internal/storage"
 
 	"github.com/aaronkim218/eventsocket"
-)
-
-const (
-	presenceMessageType = "PRESENCE"
+	"github.com/google/uuid"
 )
 
 type action string
@@ -27,25 +28,82 @@ type outgoingPresence struct {
 	Action   action           `json:"action"`
 }
 
+type outgoingProfileUpdated struct {
+	RoomID  string         `json:"room_id"`
+	Profile models.Profile `json:"profile"`
+}
+
+type presenceStatus struct {
+	UserID    string                `json:"user_id"`
+	Status    models.PresenceStatus `json:"status"`
+	Text      string                `json:"text"`
+	ExpiresAt *time.Time            `json:"expires_at"`
+}
+
+type outgoingPresenceStatus struct {
+	RoomID   string           `json:"room_id,omitempty"`
+	Statuses []presenceStatus `json:"statuses"`
+}
+
+type clientStatus struct {
+	status     models.PresenceStatus
+	text       string
+	expiresAt  *time.Time
+	lastActive time.Time
+	idle       bool
+}
+
+// visibleStatus is the status other users see, invisible clients appear
+// offline and idle clients that are otherwise online appear away
+func (cs *clientStatus) visibleStatus() models.PresenceStatus {
+	switch {
+	case cs.status == models.PresenceStatusInvisible:
+		return models.PresenceStatusOffline
+	case cs.status == models.PresenceStatusOnline && cs.idle:
+		return models.PresenceStatusAway
+	default:
+		return cs.status
+	}
+}
+
 type Presence struct {
 	eventsocket    *eventsocket.Eventsocket
+	storage        storage.Storage
 	logger         *slog.Logger
-	activeUsers    map[string]map[string]models.Profile
-	clientProfiles map[string]models.Profile
+	activeUsers    map[string]map[string]models.Profile // profile of each client as of joining, outlives the registry entry
+	profiles       *ProfileRegistry
+	clientStatuses map[string]*clientStatus
+	peers          map[string]map[string]struct{}
+	watchers       map[string]map[string]struct{}
 	mu             sync.RWMutex
+	idleTimeout    time.Duration
+	sweepInterval  time.Duration
+	done           chan struct{}
+	stopOnce       sync.Once
 }
 
 type PresenceConfig struct {
-	Eventsocket *eventsocket.Eventsocket
-	Logger      *slog.Logger
+	Eventsocket   *eventsocket.Eventsocket
+	Storage       storage.Storage
+	Logger        *slog.Logger
+	Profiles      *ProfileRegistry
+	IdleTimeout   time.Duration
+	SweepInterval time.Duration
 }
 
 func NewEventsocketPresencePlugin(cfg *PresenceConfig) *Presence {
 	plugin := &Presence{
 		eventsocket:    cfg.Eventsocket,
+		storage:        cfg.Storage,
 		logger:         cfg.Logger,
 		activeUsers:    make(map[string]map[string]models.Profile),
-		clientProfiles: make(map[string]models.Profile),
+		profiles:       cfg.Profiles,
+		clientStatuses: make(map[string]*clientStatus),
+		peers:          make(map[string]map[string]struct{}),
+		watchers:       make(map[string]map[string]struct{}),
+		idleTimeout:    cfg.IdleTimeout,
+		sweepInterval:  cfg.SweepInterval,
+		done:           make(chan struct{}),
 	}
 
 	plugin.eventsocket.OnRemoveClient("presence", plugin.unregisterClient)
@@ -70,14 +128,53 @@ func NewEventsocketPresencePlugin(cfg *PresenceConfig) *Presence {
 		}
 	})
 
+	go plugin.sweep()
+
 	return plugin
 }
 
-func (pp *Presence) RegisterClient(clientID string, profile models.Profile) {
+func (pp *Presence) RegisterClient(client *eventsocket.Client, profile models.Profile) {
+	clientID := client.ID()
+
+	peerIDs, err := pp.storage.GetRoomPeerIds(context.Background(), profile.UserId)
+	if err != nil {
+		pp.logger.Error("Failed to load presence peers",
+			slog.String("err", err.Error()),
+			slog.String("clientId", clientID),
+		)
+	}
+
 	pp.mu.Lock()
-	defer pp.mu.Unlock()
+	status := &clientStatus{
+		status:     models.PresenceStatusOnline,
+		lastActive: time.Now(),
+	}
+	pp.clientStatuses[clientID] = status
+	pp.peers[clientID] = make(map[string]struct{})
+
+	snapshot := make([]presenceStatus, 0, len(peerIDs))
+	for _, peerID := range peerIDs {
+		pp.addPeer(clientID, peerID.String())
+		snapshot = append(snapshot, pp.peerStatus(peerID.String()))
+	}
+
+	pp.notifyWatchers(clientID, pp.statusOf(clientID, status))
+
+	if err := pp.sendStatuses(clientID, protocol.UserPresenceEvent, "", snapshot); err != nil {
+		pp.logger.Error("Failed to send presence snapshot",
+			slog.String("err", err.Error()),
+			slog.String("clientId", clientID),
+		)
+	}
+	pp.mu.Unlock()
 
-	pp.clientProfiles[clientID] = profile
+	client.OnMessage(protocol.SetStatusEvent, func(data json.RawMessage) {
+		pp.handleSetStatus(clientID, data)
+	})
+
+	client.OnMessage(protocol.ActivityEvent, func(_ json.RawMessage) {
+		pp.Touch(clientID)
+	})
 
 	pp.logger.Debug("Registered client profile",
 		slog.String("clientId", clientID),
@@ -87,18 +184,53 @@ func (pp *Presence) RegisterClient(clientID string, profile models.Profile) {
 
 func (pp *Presence) unregisterClient(clientID string) {
 	pp.mu.Lock()
-	defer pp.mu.Unlock()
+	status, exists := pp.clientStatuses[clientID]
+	invisible := exists && status.status == models.PresenceStatusInvisible
+	if exists && !invisible {
+		pp.notifyWatchers(clientID, presenceStatus{UserID: clientID, Status: models.PresenceStatusOffline})
+	}
+
+	for peerID := range pp.peers[clientID] {
+		delete(pp.watchers[peerID], clientID)
+		if len(pp.watchers[peerID]) == 0 {
+			delete(pp.watchers, peerID)
+		}
+	}
 
-	delete(pp.clientProfiles, clientID)
+	delete(pp.peers, clientID)
+	delete(pp.clientStatuses, clientID)
+	pp.mu.Unlock()
 
 	pp.logger.Debug("Removed client profile on disconnect", slog.String("clientId", clientID))
+
+	// last seen is shown to other users, so recording it for an invisible
+	// session would reveal that the user was online
+	if invisible {
+		return
+	}
+
+	userID, err := uuid.Parse(clientID)
+	if err != nil {
+		pp.logger.Error("Invalid client ID for last seen",
+			slog.String("err", err.Error()),
+			slog.String("clientId", clientID),
+		)
+		return
+	}
+
+	if err := pp.storage.SetProfileLastSeen(context.Background(), userID, time.Now()); err != nil {
+		pp.logger.Error("Failed to record last seen",
+			slog.String("err", err.Error()),
+			slog.String("clientId", clientID),
+		)
+	}
 }
 
 func (pp *Presence) handleJoinRoom(roomID, clientID string) error {
 	pp.mu.Lock()
 	defer pp.mu.Unlock()
 
-	joiningProfile, exists := pp.clientProfiles[clientID]
+	joiningProfile, exists := pp.profiles.Get(clientID)
 	if !exists {
 		pp.logger.Error("Client profile not found for join",
 			slog.String("clientId", clientID),
@@ -112,8 +244,17 @@ func (pp *Presence) handleJoinRoom(roomID, clientID string) error {
 	}
 
 	var activeProfiles []models.Profile
-	for _, profile := range pp.activeUsers[roomID] {
+	var activeStatuses []presenceStatus
+	for activeClientID, profile := range pp.activeUsers[roomID] {
+		status, exists := pp.clientStatuses[activeClientID]
+		if exists && status.status == models.PresenceStatusInvisible {
+			continue
+		}
+
 		activeProfiles = append(activeProfiles, profile)
+		if exists {
+			activeStatuses = append(activeStatuses, pp.statusOf(activeClientID, status))
+		}
 	}
 
 	if len(activeProfiles) > 0 {
@@ -126,8 +267,23 @@ func (pp *Presence) handleJoinRoom(roomID, clientID string) error {
 		}
 	}
 
+	if len(activeStatuses) > 0 {
+		if err := pp.sendStatusesToClient(clientID, roomID, activeStatuses); err != nil {
+			pp.logger.Error("Failed to send existing statuses to joining client",
+				slog.String("err", err.Error()),
+				slog.String("clientId", clientID),
+				slog.String("roomId", roomID),
+			)
+		}
+	}
+
 	pp.activeUsers[roomID][clientID] = joiningProfile
 
+	status, exists := pp.clientStatuses[clientID]
+	if exists && status.status == models.PresenceStatusInvisible {
+		return nil
+	}
+
 	if err := pp.broadcastPresenceToRoom(roomID, clientID, []models.Profile{joiningProfile}, join); err != nil {
 		pp.logger.Error("Failed to broadcast user join",
 			slog.String("err", err.Error()),
@@ -137,6 +293,16 @@ func (pp *Presence) handleJoinRoom(roomID, clientID string) error {
 		return err
 	}
 
+	if exists {
+		if err := pp.broadcastStatusesToRoom(roomID, clientID, []presenceStatus{pp.statusOf(clientID, status)}); err != nil {
+			pp.logger.Error("Failed to broadcast user status",
+				slog.String("err", err.Error()),
+				slog.String("clientId", clientID),
+				slog.String("roomId", roomID),
+			)
+		}
+	}
+
 	pp.logger.Info("User joined room presence",
 		slog.String("clientId", clientID),
 		slog.String("roomId", roomID),
@@ -165,6 +331,10 @@ func (pp *Presence) handleLeaveRoom(roomID, clientID string) error {
 		delete(pp.activeUsers, roomID)
 	}
 
+	if status, exists := pp.clientStatuses[clientID]; exists && status.status == models.PresenceStatusInvisible {
+		return nil
+	}
+
 	if err := pp.broadcastPresenceToRoom(roomID, "", []models.Profile{leavingProfile}, leave); err != nil {
 		pp.logger.Error("Failed to broadcast user leave",
 			slog.String("err", err.Error()),
@@ -194,7 +364,7 @@ func (pp *Presence) sendPresenceToClient(clientID, roomID string, profiles []mod
 	}
 
 	message := eventsocket.Message{
-		Type: presenceMessageType,
+		Type: protocol.PresenceEvent,
 		Data: data,
 	}
 
@@ -220,7 +390,7 @@ func (pp *Presence) broadcastPresenceToRoom(roomID, excludeClientID string, prof
 	}
 
 	message := eventsocket.Message{
-		Type: presenceMessageType,
+		Type: protocol.PresenceEvent,
 		Data: data,
 	}
 
@@ -230,3 +400,394 @@ func (pp *Presence) broadcastPresenceToRoom(roomID, excludeClientID string, prof
 		return pp.eventsocket.BroadcastToRoom(roomID, message)
 	}
 }
+
+func (pp *Presence) handleSetStatus(clientID string, data json.RawMessage) {
+	var update protocol.SetStatus
+	if err := json.Unmarshal(data, &update); err != nil {
+		pp.logger.Error("Failed to parse SET_STATUS payload",
+			slog.String("err", err.Error()),
+			slog.String("clientId", clientID),
+		)
+		return
+	}
+
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+
+	status, exists := pp.clientStatuses[clientID]
+	if !exists {
+		pp.logger.Error("Client status not found for SET_STATUS", slog.String("clientId", clientID))
+		return
+	}
+
+	wasInvisible := status.status == models.PresenceStatusInvisible
+
+	status.status = update.Status
+	status.text = update.Text
+	status.expiresAt = update.ExpiresAt
+	status.lastActive = time.Now()
+	status.idle = false
+
+	pp.publishStatus(clientID, status, wasInvisible)
+
+	pp.logger.Debug("Client status updated",
+		slog.String("clientId", clientID),
+		slog.String("status", string(update.Status)),
+	)
+}
+
+// Touch records activity from the client, bringing it back from idle. It is
+// called for every frame the client sends.
+func (pp *Presence) Touch(clientID string) {
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+
+	status, exists := pp.clientStatuses[clientID]
+	if !exists {
+		return
+	}
+
+	status.lastActive = time.Now()
+
+	if !status.idle {
+		return
+	}
+
+	status.idle = false
+
+	if status.status == models.PresenceStatusOnline {
+		pp.publishStatus(clientID, status, false)
+	}
+}
+
+// publishStatus acknowledges the client's status to itself and broadcasts the
+// visible status to every room the client is active in, moving the client in
+// or out of the room presence lists when it switches to or from invisible.
+// The caller must hold pp.mu.
+func (pp *Presence) publishStatus(clientID string, status *clientStatus, wasInvisible bool) {
+	self := pp.statusOf(clientID, status)
+	self.Status = status.status
+
+	if err := pp.sendStatusesToClient(clientID, "", []presenceStatus{self}); err != nil {
+		pp.logger.Error("Failed to acknowledge status",
+			slog.String("err", err.Error()),
+			slog.String("clientId", clientID),
+		)
+	}
+
+	isInvisible := status.status == models.PresenceStatusInvisible
+	visible := pp.statusOf(clientID, status)
+
+	if !isInvisible || !wasInvisible {
+		pp.notifyWatchers(clientID, visible)
+	}
+
+	for roomID, clients := range pp.activeUsers {
+		profile, active := clients[clientID]
+		if !active {
+			continue
+		}
+
+		var err error
+		switch {
+		case isInvisible && wasInvisible:
+			continue
+		case isInvisible:
+			err = pp.broadcastPresenceToRoom(roomID, clientID, []models.Profile{profile}, leave)
+		case wasInvisible:
+			err = pp.broadcastPresenceToRoom(roomID, clientID, []models.Profile{profile}, join)
+		}
+		if err == nil {
+			err = pp.broadcastStatusesToRoom(roomID, clientID, []presenceStatus{visible})
+		}
+
+		if err != nil {
+			pp.logger.Error("Failed to broadcast user status",
+				slog.String("err", err.Error()),
+				slog.String("clientId", clientID),
+				slog.String("roomId", roomID),
+			)
+		}
+	}
+}
+
+// PublishProfile records the user's updated profile and sends it to every room
+// they are active in. Invisible users are skipped so the update does not reveal
+// them.
+func (pp *Presence) PublishProfile(profile models.Profile) {
+	clientID := profile.UserId.String()
+
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+
+	for _, clients := range pp.activeUsers {
+		if _, active := clients[clientID]; active {
+			clients[clientID] = profile
+		}
+	}
+
+	if status, exists := pp.clientStatuses[clientID]; !exists || status.status == models.PresenceStatusInvisible {
+		return
+	}
+
+	for roomID, clients := range pp.activeUsers {
+		if _, active := clients[clientID]; !active {
+			continue
+		}
+
+		data, err := json.Marshal(outgoingProfileUpdated{
+			RoomID:  roomID,
+			Profile: profile,
+		})
+		if err != nil {
+			pp.logger.Error("Failed to marshal profile update",
+				slog.String("err", err.Error()),
+				slog.String("clientId", clientID),
+			)
+			return
+		}
+
+		message := eventsocket.Message{
+			Type: protocol.ProfileUpdatedEvent,
+			Data: data,
+		}
+
+		if err := pp.eventsocket.BroadcastToRoom(roomID, message); err != nil {
+			pp.logger.Error("Failed to broadcast profile update",
+				slog.String("err", err.Error()),
+				slog.String("clientId", clientID),
+				slog.String("roomId", roomID),
+			)
+		}
+	}
+}
+
+// LinkPeers subscribes the user and each peer to one another's presence after
+// they come to share a room or dm, sending each connected side the other's
+// current status
+func (pp *Presence) LinkPeers(userID string, peerIDs []string) {
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+
+	for _, peerID := range peerIDs {
+		if peerID == userID {
+			continue
+		}
+
+		pp.linkPeer(userID, peerID)
+		pp.linkPeer(peerID, userID)
+	}
+}
+
+// linkPeer subscribes a connected client to the peer's presence if it is not
+// already. The caller must hold pp.mu.
+func (pp *Presence) linkPeer(clientID, peerID string) {
+	if _, connected := pp.clientStatuses[clientID]; !connected {
+		return
+	}
+
+	if _, linked := pp.peers[clientID][peerID]; linked {
+		return
+	}
+
+	pp.addPeer(clientID, peerID)
+
+	if err := pp.sendStatuses(clientID, protocol.UserPresenceEvent, "", []presenceStatus{pp.peerStatus(peerID)}); err != nil {
+		pp.logger.Error("Failed to send peer presence",
+			slog.String("err", err.Error()),
+			slog.String("clientId", clientID),
+		)
+	}
+}
+
+// UnlinkPeers unsubscribes the user and each peer from one another's presence
+// once they no longer share a room or dm, telling each connected side the
+// other is offline so it does not keep showing a stale status
+func (pp *Presence) UnlinkPeers(userID string, peerIDs []string) {
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+
+	for _, peerID := range peerIDs {
+		pp.unlinkPeer(userID, peerID)
+		pp.unlinkPeer(peerID, userID)
+	}
+}
+
+// LinkedPeers returns the users linked to the user's presence in either
+// direction
+func (pp *Presence) LinkedPeers(userID string) []string {
+	pp.mu.RLock()
+	defer pp.mu.RUnlock()
+
+	linked := make(map[string]struct{}, len(pp.peers[userID])+len(pp.watchers[userID]))
+	for peerID := range pp.peers[userID] {
+		linked[peerID] = struct{}{}
+	}
+	for watcherID := range pp.watchers[userID] {
+		linked[watcherID] = struct{}{}
+	}
+
+	peerIDs := make([]string, 0, len(linked))
+	for peerID := range linked {
+		peerIDs = append(peerIDs, peerID)
+	}
+
+	return peerIDs
+}
+
+// unlinkPeer unsubscribes a connected client from the peer's presence. The
+// caller must hold pp.mu.
+func (pp *Presence) unlinkPeer(clientID, peerID string) {
+	if _, linked := pp.peers[clientID][peerID]; !linked {
+		return
+	}
+
+	delete(pp.peers[clientID], peerID)
+	delete(pp.watchers[peerID], clientID)
+	if len(pp.watchers[peerID]) == 0 {
+		delete(pp.watchers, peerID)
+	}
+
+	offline := presenceStatus{UserID: peerID, Status: models.PresenceStatusOffline}
+	if err := pp.sendStatuses(clientID, protocol.UserPresenceEvent, "", []presenceStatus{offline}); err != nil {
+		pp.logger.Error("Failed to send peer presence",
+			slog.String("err", err.Error()),
+			slog.String("clientId", clientID),
+		)
+	}
+}
+
+// addPeer records that the connected client follows the peer's presence. The
+// caller must hold pp.mu.
+func (pp *Presence) addPeer(clientID, peerID string) {
+	pp.peers[clientID][peerID] = struct{}{}
+
+	if pp.watchers[peerID] == nil {
+		pp.watchers[peerID] = make(map[string]struct{})
+	}
+	pp.watchers[peerID][clientID] = struct{}{}
+}
+
+// peerStatus returns the status other users see for the user, offline when
+// they are not connected. The caller must hold pp.mu.
+func (pp *Presence) peerStatus(userID string) presenceStatus {
+	status, connected := pp.clientStatuses[userID]
+	if !connected {
+		return presenceStatus{UserID: userID, Status: models.PresenceStatusOffline}
+	}
+
+	return pp.statusOf(userID, status)
+}
+
+// notifyWatchers sends the client's visible status to every connected client
+// following its presence. The caller must hold pp.mu.
+func (pp *Presence) notifyWatchers(clientID string, status presenceStatus) {
+	for watcherID := range pp.watchers[clientID] {
+		if err := pp.sendStatuses(watcherID, protocol.UserPresenceEvent, "", []presenceStatus{status}); err != nil {
+			pp.logger.Error("Failed to send user presence",
+				slog.String("err", err.Error()),
+				slog.String("clientId", watcherID),
+				slog.String("userId", clientID),
+			)
+		}
+	}
+}
+
+// statusOf builds the status payload other users see for the client. The
+// caller must hold pp.mu.
+func (pp *Presence) statusOf(clientID string, status *clientStatus) presenceStatus {
+	visible := presenceStatus{
+		UserID: clientID,
+		Status: status.visibleStatus(),
+	}
+
+	if status.status != models.PresenceStatusInvisible {
+		visible.Text = status.text
+		visible.ExpiresAt = status.expiresAt
+	}
+
+	return visible
+}
+
+func (pp *Presence) sendStatusesToClient(clientID, roomID string, statuses []presenceStatus) error {
+	return pp.sendStatuses(clientID, protocol.PresenceStatusEvent, roomID, statuses)
+}
+
+func (pp *Presence) sendStatuses(clientID, messageType, roomID string, statuses []presenceStatus) error {
+	data, err := json.Marshal(outgoingPresenceStatus{
+		RoomID:   roomID,
+		Statuses: statuses,
+	})
+	if err != nil {
+		return err
+	}
+
+	message := eventsocket.Message{
+		Type: messageType,
+		Data: data,
+	}
+
+	return pp.eventsocket.BroadcastToClient(clientID, message)
+}
+
+func (pp *Presence) broadcastStatusesToRoom(roomID, excludeClientID string, statuses []presenceStatus) error {
+	data, err := json.Marshal(outgoingPresenceStatus{
+		RoomID:   roomID,
+		Statuses: statuses,
+	})
+	if err != nil {
+		return err
+	}
+
+	message := eventsocket.Message{
+		Type: protocol.PresenceStatusEvent,
+		Data: data,
+	}
+
+	return pp.eventsocket.BroadcastToRoomExcept(roomID, excludeClientID, message)
+}
+
+// sweep marks clients idle once they have sent no activity for the idle
+// timeout and clears custom statuses whose expiry has passed
+func (pp *Presence) sweep() {
+	ticker := time.NewTicker(pp.sweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-pp.done:
+			return
+		case <-ticker.C:
+		}
+
+		now := time.Now()
+
+		pp.mu.Lock()
+		for clientID, status := range pp.clientStatuses {
+			changed := false
+			wasInvisible := status.status == models.PresenceStatusInvisible
+
+			if status.expiresAt != nil && !now.Before(*status.expiresAt) {
+				status.status = models.PresenceStatusOnline
+				status.text = ""
+				status.expiresAt = nil
+				changed = true
+			}
+
+			if !status.idle && now.Sub(status.lastActive) > pp.idleTimeout {
+				status.idle = true
+				changed = changed || status.status == models.PresenceStatusOnline
+			}
+
+			if changed {
+				pp.publishStatus(clientID, status, wasInvisible)
+			}
+		}
+		pp.mu.Unlock()
+	}
+}
+
+// Stop ends the background sweep
+func (pp *Presence) Stop() {
+	pp.stopOnce.Do(func() { close(pp.done) })
+}
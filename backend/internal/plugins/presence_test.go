@@ -0,0 +1,121 @@
+package plugins
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"go-chat/internal/models"
+	"go-chat/internal/protocol"
+	"go-chat/internal/storage"
+
+	"github.com/aaronkim218/eventsocket"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// presenceStorage is the storage the presence plugin reaches while a client is
+// connected, every other method is left unimplemented
+type presenceStorage struct {
+	storage.Storage
+}
+
+func (presenceStorage) GetRoomPeerIds(_ context.Context, _ uuid.UUID) ([]uuid.UUID, error) {
+	return nil, nil
+}
+
+func (presenceStorage) SetProfileLastSeen(_ context.Context, _ uuid.UUID, _ time.Time) error {
+	return nil
+}
+
+// chattySocket is a websocket that sends the same frame at every tick until
+// closed
+type chattySocket struct {
+	frame  []byte
+	ticker *time.Ticker
+	closed chan struct{}
+	once   sync.Once
+}
+
+func newChattySocket(frame string, interval time.Duration) *chattySocket {
+	return &chattySocket{
+		frame:  []byte(frame),
+		ticker: time.NewTicker(interval),
+		closed: make(chan struct{}),
+	}
+}
+
+func (cs *chattySocket) ReadMessage() (int, []byte, error) {
+	select {
+	case <-cs.closed:
+		return 0, nil, io.EOF
+	case <-cs.ticker.C:
+		return 1, cs.frame, nil
+	}
+}
+
+func (cs *chattySocket) WriteJSON(_ any) error {
+	return nil
+}
+
+func (cs *chattySocket) Close() error {
+	cs.once.Do(func() {
+		cs.ticker.Stop()
+		close(cs.closed)
+	})
+	return nil
+}
+
+func (pp *Presence) isIdle(clientID string) bool {
+	pp.mu.RLock()
+	defer pp.mu.RUnlock()
+
+	return pp.clientStatuses[clientID].idle
+}
+
+func TestPresence_FramesKeepClientActive(t *testing.T) {
+	const idleTimeout = 50 * time.Millisecond
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	es := eventsocket.New()
+	presence := NewEventsocketPresencePlugin(&PresenceConfig{
+		Eventsocket:   es,
+		Storage:       presenceStorage{},
+		Logger:        logger,
+		Profiles:      NewProfileRegistry(&ProfileRegistryConfig{Eventsocket: es, Logger: logger}),
+		IdleTimeout:   idleTimeout,
+		SweepInterval: 5 * time.Millisecond,
+	})
+	t.Cleanup(presence.Stop)
+
+	chatting := models.Profile{UserId: uuid.New(), Username: "aaron"}
+	socket := newChattySocket(`{"type": "USER_MESSAGE", "data": {"room_id": "`+uuid.NewString()+`", "content": "hi"}}`, 10*time.Millisecond)
+	t.Cleanup(func() { _ = socket.Close() })
+
+	chattingClient, err := es.CreateClient(&eventsocket.CreateClientConfig{
+		ID: chatting.UserId.String(),
+		Conn: protocol.NewConn(&protocol.ConnConfig{
+			Socket:  socket,
+			Version: protocol.Version1,
+			Logger:  logger,
+			OnFrame: func() { presence.Touch(chatting.UserId.String()) },
+		}),
+	})
+	require.NoError(t, err)
+	presence.RegisterClient(chattingClient, chatting)
+
+	silent := models.Profile{UserId: uuid.New(), Username: "jane"}
+	silentClient, _ := connectClient(t, es, silent.UserId)
+	presence.RegisterClient(silentClient, silent)
+
+	assert.Eventually(t, func() bool {
+		return presence.isIdle(silent.UserId.String())
+	}, time.Second, 5*time.Millisecond, "a silent client goes idle")
+
+	time.Sleep(4 * idleTimeout)
+	assert.False(t, presence.isIdle(chatting.UserId.String()), "a client sending messages stays active")
+}
@@ -0,0 +1,78 @@
+package plugins
+
+import (
+	"log/slog"
+	"sync"
+
+	"go-chat/internal/models"
+
+	"github.com/aaronkim218/eventsocket"
+)
+
+// ProfileRegistry holds the current profile of every connected client. The
+// plugins share it so a profile change reaches all of them without the user
+// having to reconnect.
+type ProfileRegistry struct {
+	logger   *slog.Logger
+	profiles map[string]models.Profile
+	mu       sync.RWMutex
+}
+
+type ProfileRegistryConfig struct {
+	Eventsocket *eventsocket.Eventsocket
+	Logger      *slog.Logger
+}
+
+func NewProfileRegistry(cfg *ProfileRegistryConfig) *ProfileRegistry {
+	registry := &ProfileRegistry{
+		logger:   cfg.Logger,
+		profiles: make(map[string]models.Profile),
+	}
+
+	cfg.Eventsocket.OnRemoveClient("profile_registry", registry.unregisterClient)
+
+	return registry
+}
+
+func (pr *ProfileRegistry) RegisterClient(client *eventsocket.Client, profile models.Profile) {
+	pr.mu.Lock()
+	pr.profiles[client.ID()] = profile
+	pr.mu.Unlock()
+}
+
+func (pr *ProfileRegistry) unregisterClient(clientID string) {
+	pr.mu.Lock()
+	delete(pr.profiles, clientID)
+	pr.mu.Unlock()
+}
+
+// Get returns the profile of a connected client
+func (pr *ProfileRegistry) Get(clientID string) (models.Profile, bool) {
+	pr.mu.RLock()
+	defer pr.mu.RUnlock()
+
+	profile, exists := pr.profiles[clientID]
+	return profile, exists
+}
+
+// Update replaces the profile of the user's connected client, reporting
+// whether the user is connected
+func (pr *ProfileRegistry) Update(profile models.Profile) bool {
+	clientID := profile.UserId.String()
+
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	if _, exists := pr.profiles[clientID]; !exists {
+		return false
+	}
+
+	pr.profiles[clientID] = profile
+
+	pr.logger.Debug("Updated client profile",
+		slog.String("clientId", clientID),
+		slog.String("username", profile.Username),
+	)
+
+	return true
+}
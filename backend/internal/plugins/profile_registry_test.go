@@ -0,0 +1,132 @@
+package plugins
+
+import (
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"go-chat/internal/models"
+
+	"github.com/aaronkim218/eventsocket"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingConn is an eventsocket connection that stays open until closed
+type blockingConn struct {
+	closed chan struct{}
+	once   sync.Once
+}
+
+func newBlockingConn() *blockingConn {
+	return &blockingConn{closed: make(chan struct{})}
+}
+
+func (bc *blockingConn) ReadJSON(_ any) error {
+	<-bc.closed
+	return io.EOF
+}
+
+func (bc *blockingConn) WriteJSON(_ any) error {
+	return nil
+}
+
+func (bc *blockingConn) Close() error {
+	bc.once.Do(func() { close(bc.closed) })
+	return nil
+}
+
+func newTestRegistry() (*ProfileRegistry, *eventsocket.Eventsocket) {
+	es := eventsocket.New()
+	registry := NewProfileRegistry(&ProfileRegistryConfig{
+		Eventsocket: es,
+		Logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+
+	return registry, es
+}
+
+func connectClient(t *testing.T, es *eventsocket.Eventsocket, userId uuid.UUID) (*eventsocket.Client, *blockingConn) {
+	t.Helper()
+
+	conn := newBlockingConn()
+	client, err := es.CreateClient(&eventsocket.CreateClientConfig{
+		ID:   userId.String(),
+		Conn: conn,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return client, conn
+}
+
+func TestProfileRegistry_Get(t *testing.T) {
+	registry, es := newTestRegistry()
+
+	profile := models.Profile{UserId: uuid.New(), Username: "aaron"}
+	client, _ := connectClient(t, es, profile.UserId)
+	registry.RegisterClient(client, profile)
+
+	got, exists := registry.Get(profile.UserId.String())
+	assert.True(t, exists)
+	assert.Equal(t, profile, got)
+
+	_, exists = registry.Get(uuid.NewString())
+	assert.False(t, exists)
+}
+
+func TestProfileRegistry_Update(t *testing.T) {
+	registry, es := newTestRegistry()
+
+	connected := models.Profile{UserId: uuid.New(), Username: "aaron"}
+	client, _ := connectClient(t, es, connected.UserId)
+	registry.RegisterClient(client, connected)
+
+	tests := []struct {
+		name       string
+		profile    models.Profile
+		wantUpdate bool
+	}{
+		{
+			name:       "connected user",
+			profile:    models.Profile{UserId: connected.UserId, Username: "aaron.kim"},
+			wantUpdate: true,
+		},
+		{
+			name:       "disconnected user",
+			profile:    models.Profile{UserId: uuid.New(), Username: "jane"},
+			wantUpdate: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantUpdate, registry.Update(tt.profile))
+
+			got, exists := registry.Get(tt.profile.UserId.String())
+			assert.Equal(t, tt.wantUpdate, exists)
+			if tt.wantUpdate {
+				assert.Equal(t, tt.profile, got)
+			}
+		})
+	}
+}
+
+func TestProfileRegistry_RemovesDisconnectedClients(t *testing.T) {
+	registry, es := newTestRegistry()
+
+	profile := models.Profile{UserId: uuid.New(), Username: "aaron"}
+	client, conn := connectClient(t, es, profile.UserId)
+	registry.RegisterClient(client, profile)
+
+	require.NoError(t, conn.Close())
+
+	assert.Eventually(t, func() bool {
+		_, exists := registry.Get(profile.UserId.String())
+		return !exists
+	}, time.Second, 10*time.Millisecond)
+	assert.False(t, registry.Update(profile))
+}
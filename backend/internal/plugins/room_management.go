@@ -6,6 +6,7 @@ import (
 	"log/slog"
 
 	"go-chat/internal/models"
+	"go-chat/internal/protocol"
 	"go-chat/internal/storage"
 
 	"github.com/aaronkim218/eventsocket"
@@ -13,14 +14,6 @@ import (
 	"github.com/google/uuid"
 )
 
-type joinRoom struct {
-	RoomID string `json:"room_id"`
-}
-
-type leaveRoom struct {
-	RoomID string `json:"room_id"`
-}
-
 type RoomManagement struct {
 	eventsocket *eventsocket.Eventsocket
 	storage     storage.Storage
@@ -46,17 +39,17 @@ func NewRoomManagementPlugin(cfg *RoomManagementConfig) *RoomManagement {
 func (rm *RoomManagement) RegisterClient(client *eventsocket.Client, profile models.Profile) {
 	userID := profile.UserId
 
-	client.OnMessage("JOIN_ROOM", func(data json.RawMessage) {
+	client.OnMessage(protocol.JoinRoomEvent, func(data json.RawMessage) {
 		rm.handleJoinRoom(client.ID(), userID, data)
 	})
 
-	client.OnMessage("LEAVE_ROOM", func(data json.RawMessage) {
+	client.OnMessage(protocol.LeaveRoomEvent, func(data json.RawMessage) {
 		rm.handleLeaveRoom(client.ID(), userID, data)
 	})
 }
 
 func (rm *RoomManagement) handleJoinRoom(clientID string, userID uuid.UUID, data json.RawMessage) {
-	var payload joinRoom
+	var payload protocol.JoinRoom
 	if err := json.Unmarshal(data, &payload); err != nil {
 		rm.logger.Error("Failed to parse JOIN_ROOM payload",
 			slog.String("err", err.Error()),
@@ -89,6 +82,26 @@ func (rm *RoomManagement) handleJoinRoom(clientID string, userID uuid.UUID, data
 	}
 
 	if !authorized {
+		banned, err := rm.storage.CheckUserBannedFromRoom(context.Background(), roomID, userID)
+		if err != nil {
+			rm.logger.Error("Failed to check room ban",
+				slog.String("err", err.Error()),
+				slog.String("userId", userID.String()),
+				slog.String("roomId", payload.RoomID),
+			)
+			rm.sendJoinRoomError(clientID, payload.RoomID, "Failed to check room authorization")
+			return
+		}
+
+		if banned {
+			rm.logger.Warn("Banned user tried to join room",
+				slog.String("userId", userID.String()),
+				slog.String("roomId", payload.RoomID),
+			)
+			rm.sendJoinRoomError(clientID, payload.RoomID, "Banned from this room")
+			return
+		}
+
 		rm.logger.Warn("User not authorized for room",
 			slog.String("userId", userID.String()),
 			slog.String("roomId", payload.RoomID),
@@ -116,7 +129,7 @@ func (rm *RoomManagement) handleJoinRoom(clientID string, userID uuid.UUID, data
 }
 
 func (rm *RoomManagement) handleLeaveRoom(clientID string, userID uuid.UUID, data json.RawMessage) {
-	var payload leaveRoom
+	var payload protocol.LeaveRoom
 	if err := json.Unmarshal(data, &payload); err != nil {
 		rm.logger.Error("Failed to parse LEAVE_ROOM payload",
 			slog.String("err", err.Error()),
@@ -141,7 +154,7 @@ func (rm *RoomManagement) sendJoinRoomSuccess(clientID, roomID string) {
 	})
 
 	message := eventsocket.Message{
-		Type: "JOIN_ROOM_SUCCESS",
+		Type: protocol.JoinRoomSuccessEvent,
 		Data: responseData,
 	}
 
@@ -169,7 +182,7 @@ func (rm *RoomManagement) sendJoinRoomError(clientID, roomID, errorMessage strin
 	responseData, _ := json.Marshal(payloadData)
 
 	message := eventsocket.Message{
-		Type: "JOIN_ROOM_ERROR",
+		Type: protocol.JoinRoomErrorEvent,
 		Data: responseData,
 	}
 
@@ -7,15 +7,11 @@ import (
 	"time"
 
 	"go-chat/internal/models"
+	"go-chat/internal/protocol"
 
 	"github.com/aaronkim218/eventsocket"
 )
 
-type typingStatusPayload struct {
-	RoomID  string         `json:"room_id"`
-	Profile models.Profile `json:"profile"`
-}
-
 type outgoingTypingStatus struct {
 	RoomID   string           `json:"room_id"`
 	Profiles []models.Profile `json:"profiles"`
@@ -25,15 +21,18 @@ type TypingStatusPlugin struct {
 	eventsocket     *eventsocket.Eventsocket
 	logger          *slog.Logger
 	typing          map[string]map[string]time.Time
-	clientProfiles  map[string]models.Profile
+	profiles        *ProfileRegistry
 	mu              sync.RWMutex
 	timeout         time.Duration
 	cleanupInterval time.Duration
+	done            chan struct{}
+	stopOnce        sync.Once
 }
 
 type TypingStatusPluginConfig struct {
 	Eventsocket     *eventsocket.Eventsocket
 	Logger          *slog.Logger
+	Profiles        *ProfileRegistry
 	Timeout         time.Duration
 	CleanupInterval time.Duration
 }
@@ -43,9 +42,10 @@ func NewEventsocketTypingStatusPlugin(cfg *TypingStatusPluginConfig) *TypingStat
 		eventsocket:     cfg.Eventsocket,
 		logger:          cfg.Logger,
 		typing:          make(map[string]map[string]time.Time),
-		clientProfiles:  make(map[string]models.Profile),
+		profiles:        cfg.Profiles,
 		timeout:         cfg.Timeout,
 		cleanupInterval: cfg.CleanupInterval,
+		done:            make(chan struct{}),
 	}
 
 	plugin.eventsocket.OnRemoveClient("typing_status", plugin.unregisterClient)
@@ -78,11 +78,7 @@ func NewEventsocketTypingStatusPlugin(cfg *TypingStatusPluginConfig) *TypingStat
 func (ts *TypingStatusPlugin) RegisterClient(client *eventsocket.Client, profile models.Profile) {
 	clientID := client.ID()
 
-	ts.mu.Lock()
-	ts.clientProfiles[clientID] = profile
-	ts.mu.Unlock()
-
-	client.OnMessage("TYPING_STATUS", func(data json.RawMessage) {
+	client.OnMessage(protocol.TypingStatusEvent, func(data json.RawMessage) {
 		ts.HandleTypingStatus(clientID, data)
 	})
 
@@ -96,8 +92,6 @@ func (ts *TypingStatusPlugin) unregisterClient(clientID string) {
 	ts.mu.Lock()
 	defer ts.mu.Unlock()
 
-	delete(ts.clientProfiles, clientID)
-
 	for roomID, clients := range ts.typing {
 		delete(clients, clientID)
 		if len(clients) == 0 {
@@ -134,7 +128,7 @@ func (ts *TypingStatusPlugin) handleLeaveRoom(roomID, clientID string) error {
 }
 
 func (ts *TypingStatusPlugin) HandleTypingStatus(clientID string, data json.RawMessage) {
-	var payload typingStatusPayload
+	var payload protocol.TypingStatus
 	if err := json.Unmarshal(data, &payload); err != nil {
 		ts.logger.Error("Failed to parse TYPING_STATUS payload",
 			slog.String("err", err.Error()),
@@ -147,9 +141,7 @@ func (ts *TypingStatusPlugin) HandleTypingStatus(clientID string, data json.RawM
 
 	ts.setClientTyping(roomID, clientID)
 
-	ts.mu.RLock()
-	profile, exists := ts.clientProfiles[clientID]
-	ts.mu.RUnlock()
+	profile, exists := ts.profiles.Get(clientID)
 
 	if !exists {
 		ts.logger.Error("Client profile not found for typing status broadcast",
@@ -186,7 +178,7 @@ func (ts *TypingStatusPlugin) getTypingProfiles(roomID, excludeClientID string)
 	var profiles []models.Profile
 	for clientID := range clients {
 		if clientID != excludeClientID {
-			if profile, exists := ts.clientProfiles[clientID]; exists {
+			if profile, exists := ts.profiles.Get(clientID); exists {
 				profiles = append(profiles, profile)
 			}
 		}
@@ -218,7 +210,7 @@ func (ts *TypingStatusPlugin) sendTypingStatusToClient(clientID, roomID string,
 	}
 
 	message := eventsocket.Message{
-		Type: "TYPING_STATUS",
+		Type: protocol.TypingStatusEvent,
 		Data: responseData,
 	}
 
@@ -237,7 +229,7 @@ func (ts *TypingStatusPlugin) broadcastTypingStatusToRoom(roomID, excludeClientI
 	}
 
 	message := eventsocket.Message{
-		Type: "TYPING_STATUS",
+		Type: protocol.TypingStatusEvent,
 		Data: responseData,
 	}
 
@@ -252,7 +244,13 @@ func (ts *TypingStatusPlugin) cleanup() {
 	ticker := time.NewTicker(ts.cleanupInterval)
 	defer ticker.Stop()
 
-	for range ticker.C {
+	for {
+		select {
+		case <-ts.done:
+			return
+		case <-ticker.C:
+		}
+
 		ts.mu.Lock()
 		for roomID, clients := range ts.typing {
 			for clientID, timestamp := range clients {
@@ -268,3 +266,8 @@ func (ts *TypingStatusPlugin) cleanup() {
 		ts.mu.Unlock()
 	}
 }
+
+// Stop ends the background cleanup
+func (ts *TypingStatusPlugin) Stop() {
+	ts.stopOnce.Do(func() { close(ts.done) })
+}
@@ -3,46 +3,69 @@ package plugins
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
 	"go-chat/internal/models"
+	"go-chat/internal/protocol"
 	"go-chat/internal/storage"
 	"go-chat/internal/types"
+	"go-chat/internal/usernames"
 
 	"github.com/aaronkim218/eventsocket"
 
 	"github.com/google/uuid"
 )
 
-type userMessagePayload struct {
-	RoomID  string `json:"room_id"`
-	Content string `json:"content"`
+type userMessageError struct {
+	RoomID     string     `json:"room_id"`
+	Message    string     `json:"message"`
+	RetryAfter *time.Time `json:"retry_after,omitempty"`
+}
+
+type messageNotification struct {
+	RoomID    string    `json:"room_id"`
+	MessageID uuid.UUID `json:"message_id"`
+	Mentioned bool      `json:"mentioned"`
 }
 
 type UserMessagePlugin struct {
-	eventsocket    *eventsocket.Eventsocket
-	storage        storage.Storage
-	logger         *slog.Logger
-	clientProfiles map[string]models.Profile
-	mu             sync.RWMutex
+	eventsocket *eventsocket.Eventsocket
+	storage     storage.Storage
+	logger      *slog.Logger
+	profiles    *ProfileRegistry
+	usernames   *usernames.Policy
+	// rooms tracks the clients that joined each room so that a message can be
+	// delivered per client when some of them have blocked its author
+	rooms map[string]map[string]struct{}
+	mu    sync.RWMutex
 }
 
 type UserMessagePluginConfig struct {
 	Eventsocket *eventsocket.Eventsocket
 	Storage     storage.Storage
 	Logger      *slog.Logger
+	Profiles    *ProfileRegistry
+	Usernames   *usernames.Policy
 }
 
 func NewEventsocketUserMessagePlugin(cfg *UserMessagePluginConfig) *UserMessagePlugin {
 	plugin := &UserMessagePlugin{
-		eventsocket:    cfg.Eventsocket,
-		storage:        cfg.Storage,
-		logger:         cfg.Logger,
-		clientProfiles: make(map[string]models.Profile),
+		eventsocket: cfg.Eventsocket,
+		storage:     cfg.Storage,
+		logger:      cfg.Logger,
+		profiles:    cfg.Profiles,
+		usernames:   cfg.Usernames,
+		rooms:       make(map[string]map[string]struct{}),
 	}
 
+	plugin.eventsocket.OnJoinRoom("user_message", plugin.handleJoinRoom)
+	plugin.eventsocket.OnLeaveRoom("user_message", plugin.handleLeaveRoom)
 	plugin.eventsocket.OnRemoveClient("user_message", plugin.unregisterClient)
 
 	return plugin
@@ -52,11 +75,7 @@ func (um *UserMessagePlugin) RegisterClient(client *eventsocket.Client, profile
 	userID := profile.UserId
 	clientID := client.ID()
 
-	um.mu.Lock()
-	um.clientProfiles[clientID] = profile
-	um.mu.Unlock()
-
-	client.OnMessage("USER_MESSAGE", func(data json.RawMessage) {
+	client.OnMessage(protocol.UserMessageEvent, func(data json.RawMessage) {
 		um.handleUserMessage(clientID, userID, data)
 	})
 
@@ -70,15 +89,37 @@ func (um *UserMessagePlugin) unregisterClient(clientID string) {
 	um.mu.Lock()
 	defer um.mu.Unlock()
 
-	delete(um.clientProfiles, clientID)
+	for roomID, clients := range um.rooms {
+		delete(clients, clientID)
+		if len(clients) == 0 {
+			delete(um.rooms, roomID)
+		}
+	}
+}
 
-	um.logger.Debug("Unregistered client from user messages",
-		slog.String("clientId", clientID),
-	)
+func (um *UserMessagePlugin) handleJoinRoom(roomID, clientID string) {
+	um.mu.Lock()
+	defer um.mu.Unlock()
+
+	if um.rooms[roomID] == nil {
+		um.rooms[roomID] = make(map[string]struct{})
+	}
+
+	um.rooms[roomID][clientID] = struct{}{}
+}
+
+func (um *UserMessagePlugin) handleLeaveRoom(roomID, clientID string) {
+	um.mu.Lock()
+	defer um.mu.Unlock()
+
+	delete(um.rooms[roomID], clientID)
+	if len(um.rooms[roomID]) == 0 {
+		delete(um.rooms, roomID)
+	}
 }
 
 func (um *UserMessagePlugin) handleUserMessage(clientID string, userID uuid.UUID, data json.RawMessage) {
-	var payload userMessagePayload
+	var payload protocol.UserMessage
 	if err := json.Unmarshal(data, &payload); err != nil {
 		um.logger.Error("Failed to parse USER_MESSAGE payload",
 			slog.String("err", err.Error()),
@@ -94,9 +135,126 @@ func (um *UserMessagePlugin) handleUserMessage(clientID string, userID uuid.UUID
 			slog.String("roomId", payload.RoomID),
 			slog.String("userId", userID.String()),
 		)
+		um.sendUserMessageError(clientID, payload.RoomID, "Invalid room ID")
 		return
 	}
 
+	inRoom, err := um.storage.CheckUserInRoom(context.Background(), roomID, userID)
+	if err != nil {
+		um.logger.Error("Failed to check room membership for user message",
+			slog.String("err", err.Error()),
+			slog.String("roomId", payload.RoomID),
+			slog.String("userId", userID.String()),
+		)
+		um.sendUserMessageError(clientID, payload.RoomID, "Failed to send message")
+		return
+	}
+
+	// banned users lose their membership so this also stops them from posting
+	if !inRoom {
+		um.logger.Warn("Rejected user message from non member",
+			slog.String("roomId", payload.RoomID),
+			slog.String("userId", userID.String()),
+		)
+		um.sendUserMessageError(clientID, payload.RoomID, "Not a member of this room")
+		return
+	}
+
+	room, err := um.storage.GetRoomById(context.Background(), roomID)
+	if err != nil {
+		um.logger.Error("Failed to get room for user message",
+			slog.String("err", err.Error()),
+			slog.String("roomId", payload.RoomID),
+			slog.String("userId", userID.String()),
+		)
+		um.sendUserMessageError(clientID, payload.RoomID, "Failed to send message")
+		return
+	}
+
+	if room.ArchivedAt != nil {
+		um.logger.Warn("Rejected user message to archived room",
+			slog.String("roomId", payload.RoomID),
+			slog.String("userId", userID.String()),
+		)
+		um.sendUserMessageError(clientID, payload.RoomID, "Room is archived")
+		return
+	}
+
+	// the host and admins are exempt from both the posting policy and slow mode
+	var slowMode time.Duration
+	if room.PostingPolicy == models.PostingPolicyAdmins || room.SlowModeSeconds > 0 {
+		isAdmin, err := um.storage.CheckUserIsRoomAdmin(context.Background(), roomID, userID)
+		if err != nil {
+			um.logger.Error("Failed to check posting permission",
+				slog.String("err", err.Error()),
+				slog.String("roomId", payload.RoomID),
+				slog.String("userId", userID.String()),
+			)
+			um.sendUserMessageError(clientID, payload.RoomID, "Failed to send message")
+			return
+		}
+
+		if !isAdmin && room.PostingPolicy == models.PostingPolicyAdmins {
+			um.logger.Warn("Rejected user message to announcement room",
+				slog.String("roomId", payload.RoomID),
+				slog.String("userId", userID.String()),
+			)
+			um.sendUserMessageError(clientID, payload.RoomID, "Only the host and admins can post in this room")
+			return
+		}
+
+		if !isAdmin {
+			slowMode = time.Duration(room.SlowModeSeconds) * time.Second
+		}
+	}
+
+	mutedUntil, err := um.storage.GetRoomMuteExpiry(context.Background(), roomID, userID)
+	if err != nil {
+		um.logger.Error("Failed to check mute for user message",
+			slog.String("err", err.Error()),
+			slog.String("roomId", payload.RoomID),
+			slog.String("userId", userID.String()),
+		)
+		um.sendUserMessageError(clientID, payload.RoomID, "Failed to send message")
+		return
+	}
+
+	if mutedUntil != nil {
+		um.logger.Warn("Rejected user message from muted user",
+			slog.String("roomId", payload.RoomID),
+			slog.String("userId", userID.String()),
+		)
+		um.sendUserMessageErrorPayload(clientID, userMessageError{
+			RoomID:     payload.RoomID,
+			Message:    fmt.Sprintf("Muted until %s", mutedUntil.UTC().Format(time.RFC3339)),
+			RetryAfter: mutedUntil,
+		})
+		return
+	}
+
+	mentions := um.usernames.Mentions(payload.Content)
+	if len(mentions) > 0 {
+		blockers, err := um.storage.GetMentionBlockers(context.Background(), userID, mentions)
+		if err != nil {
+			um.logger.Error("Failed to check mentions for user message",
+				slog.String("err", err.Error()),
+				slog.String("roomId", payload.RoomID),
+				slog.String("userId", userID.String()),
+			)
+			um.sendUserMessageError(clientID, payload.RoomID, "Failed to send message")
+			return
+		}
+
+		if len(blockers) > 0 {
+			um.logger.Warn("Rejected user message mentioning blockers",
+				slog.String("roomId", payload.RoomID),
+				slog.String("userId", userID.String()),
+			)
+			um.sendUserMessageError(clientID, payload.RoomID, fmt.Sprintf("Cannot mention %s", strings.Join(blockers, ", ")))
+			return
+		}
+	}
+
 	messageID, err := uuid.NewRandom()
 	if err != nil {
 		um.logger.Error("Failed to generate message ID",
@@ -109,13 +267,15 @@ func (um *UserMessagePlugin) handleUserMessage(clientID string, userID uuid.UUID
 	message := models.Message{
 		Id:        messageID,
 		RoomId:    roomID,
-		Author:    userID,
+		Author:    &userID,
 		Content:   payload.Content,
+		Kind:      models.MessageKindUser,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
 
-	if err := um.storage.CreateMessage(context.Background(), message); err != nil {
+	retryAfter, err := um.storage.CreateUserMessage(context.Background(), message, slowMode)
+	if err != nil {
 		um.logger.Error("Failed to create message",
 			slog.String("err", err.Error()),
 			slog.String("messageId", messageID.String()),
@@ -125,9 +285,20 @@ func (um *UserMessagePlugin) handleUserMessage(clientID string, userID uuid.UUID
 		return
 	}
 
-	um.mu.RLock()
-	profile, exists := um.clientProfiles[clientID]
-	um.mu.RUnlock()
+	if retryAfter != nil {
+		um.logger.Debug("Rejected user message during slow mode",
+			slog.String("roomId", payload.RoomID),
+			slog.String("userId", userID.String()),
+		)
+		um.sendUserMessageErrorPayload(clientID, userMessageError{
+			RoomID:     payload.RoomID,
+			Message:    fmt.Sprintf("Slow mode is on, try again after %s", retryAfter.UTC().Format(time.RFC3339)),
+			RetryAfter: retryAfter,
+		})
+		return
+	}
+
+	profile, exists := um.profiles.Get(clientID)
 
 	if !exists {
 		um.logger.Error("Client profile not found for message broadcast",
@@ -142,9 +313,20 @@ func (um *UserMessagePlugin) handleUserMessage(clientID string, userID uuid.UUID
 		Username:  profile.Username,
 		FirstName: profile.FirstName,
 		LastName:  profile.LastName,
+		AvatarUrl: profile.AvatarUrl,
 	}
 
-	if err := um.broadcastUserMessage(payload.RoomID, userMessage); err != nil {
+	blockerIDs, err := um.storage.GetRoomBlockerIds(context.Background(), roomID, userID)
+	if err != nil {
+		um.logger.Error("Failed to get blockers for user message",
+			slog.String("err", err.Error()),
+			slog.String("messageId", messageID.String()),
+			slog.String("roomId", payload.RoomID),
+		)
+		return
+	}
+
+	if err := um.broadcastUserMessage(payload.RoomID, userMessage, blockerIDs); err != nil {
 		um.logger.Error("Failed to broadcast user message",
 			slog.String("err", err.Error()),
 			slog.String("messageId", messageID.String()),
@@ -153,6 +335,8 @@ func (um *UserMessagePlugin) handleUserMessage(clientID string, userID uuid.UUID
 		return
 	}
 
+	um.notifyMembers(roomID, userID, messageID, blockerIDs, mentions)
+
 	um.logger.Info("User message processed successfully",
 		slog.String("messageId", messageID.String()),
 		slog.String("userId", userID.String()),
@@ -161,16 +345,157 @@ func (um *UserMessagePlugin) handleUserMessage(clientID string, userID uuid.UUID
 	)
 }
 
-func (um *UserMessagePlugin) broadcastUserMessage(roomID string, userMessage types.UserMessage) error {
+// broadcastUserMessage sends the message to every client in the room, flagging
+// it for the clients whose users have blocked its author
+func (um *UserMessagePlugin) broadcastUserMessage(roomID string, userMessage types.UserMessage, blockerIDs []uuid.UUID) error {
 	payload, err := json.Marshal(userMessage)
 	if err != nil {
 		return err
 	}
 
 	message := eventsocket.Message{
-		Type: "USER_MESSAGE",
+		Type: protocol.UserMessageEvent,
 		Data: payload,
 	}
 
-	return um.eventsocket.BroadcastToRoom(roomID, message)
+	if len(blockerIDs) == 0 {
+		return um.eventsocket.BroadcastToRoom(roomID, message)
+	}
+
+	userMessage.AuthorBlocked = true
+	blockedPayload, err := json.Marshal(userMessage)
+	if err != nil {
+		return err
+	}
+
+	blockedMessage := eventsocket.Message{
+		Type: protocol.UserMessageEvent,
+		Data: blockedPayload,
+	}
+
+	blockers := make(map[string]struct{}, len(blockerIDs))
+	for _, blockerID := range blockerIDs {
+		blockers[blockerID.String()] = struct{}{}
+	}
+
+	um.mu.RLock()
+	clientIDs := make([]string, 0, len(um.rooms[roomID]))
+	for clientID := range um.rooms[roomID] {
+		clientIDs = append(clientIDs, clientID)
+	}
+	um.mu.RUnlock()
+
+	for _, clientID := range clientIDs {
+		msg := message
+		if _, blocked := blockers[clientID]; blocked {
+			msg = blockedMessage
+		}
+
+		if err := um.eventsocket.BroadcastToClient(clientID, msg); err != nil {
+			um.logger.Debug("Failed to send user message to client",
+				slog.String("err", err.Error()),
+				slog.String("clientId", clientID),
+				slog.String("roomId", roomID),
+			)
+		}
+	}
+
+	return nil
+}
+
+// notifyMembers tells the members who are not in the room about the message
+func (um *UserMessagePlugin) notifyMembers(roomID uuid.UUID, authorID uuid.UUID, messageID uuid.UUID, blockerIDs []uuid.UUID, mentions []string) {
+	targets, err := um.storage.GetRoomNotificationTargets(context.Background(), roomID, authorID)
+	if err != nil {
+		um.logger.Error("Failed to get notification targets",
+			slog.String("err", err.Error()),
+			slog.String("messageId", messageID.String()),
+			slog.String("roomId", roomID.String()),
+		)
+		return
+	}
+
+	um.mu.RLock()
+	notifications := messageNotifications(targets, um.rooms[roomID.String()], blockerIDs, mentions)
+	um.mu.RUnlock()
+
+	for clientID, mentioned := range notifications {
+		data, err := json.Marshal(messageNotification{
+			RoomID:    roomID.String(),
+			MessageID: messageID,
+			Mentioned: mentioned,
+		})
+		if err != nil {
+			um.logger.Error("Failed to marshal MESSAGE_NOTIFICATION",
+				slog.String("err", err.Error()),
+				slog.String("clientId", clientID),
+			)
+			continue
+		}
+
+		message := eventsocket.Message{
+			Type: protocol.MessageNotificationEvent,
+			Data: data,
+		}
+
+		if err := um.eventsocket.BroadcastToClient(clientID, message); err != nil && !errors.Is(err, eventsocket.ErrClientNotFound) {
+			um.logger.Error("Failed to send MESSAGE_NOTIFICATION",
+				slog.String("err", err.Error()),
+				slog.String("clientId", clientID),
+			)
+		}
+	}
+}
+
+// messageNotifications picks the members to notify of a message, keyed by client id and reporting whether they were
+// mentioned. Members already in the room see the message itself, and members who muted the room or blocked the author
+// are never notified, not even of mentions.
+func messageNotifications(targets []types.NotificationTarget, inRoom map[string]struct{}, blockerIDs []uuid.UUID, mentions []string) map[string]bool {
+	notifications := make(map[string]bool)
+	for _, target := range targets {
+		clientID := target.UserId.String()
+		if _, active := inRoom[clientID]; active || target.Muted || slices.Contains(blockerIDs, target.UserId) {
+			continue
+		}
+
+		notifications[clientID] = slices.Contains(mentions, strings.ToLower(target.Username))
+	}
+
+	return notifications
+}
+
+func (um *UserMessagePlugin) sendUserMessageError(clientID, roomID, errorMessage string) {
+	um.sendUserMessageErrorPayload(clientID, userMessageError{
+		RoomID:  roomID,
+		Message: errorMessage,
+	})
+}
+
+func (um *UserMessagePlugin) sendUserMessageErrorPayload(clientID string, payload userMessageError) {
+	responseData, err := json.Marshal(payload)
+	if err != nil {
+		um.logger.Error("Failed to marshal USER_MESSAGE_ERROR",
+			slog.String("err", err.Error()),
+			slog.String("roomId", payload.RoomID),
+			slog.String("clientId", clientID),
+		)
+		return
+	}
+
+	message := eventsocket.Message{
+		Type: protocol.UserMessageErrorEvent,
+		Data: responseData,
+	}
+
+	if err := um.eventsocket.BroadcastToClient(clientID, message); err != nil {
+		um.logger.Error("Failed to send USER_MESSAGE_ERROR",
+			slog.String("err", err.Error()),
+			slog.String("roomId", payload.RoomID),
+			slog.String("message", payload.Message),
+			slog.String("clientId", clientID),
+		)
+		return
+	}
+
+	um.logger.Debug("Sent USER_MESSAGE_ERROR", slog.String("roomId", payload.RoomID), slog.String("message", payload.Message), slog.String("clientId", clientID))
 }
@@ -0,0 +1,44 @@
+package plugins
+
+import (
+	"testing"
+
+	"go-chat/internal/types"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageNotifications(t *testing.T) {
+	away := types.NotificationTarget{UserId: uuid.New(), Username: "Aaron"}
+	muted := types.NotificationTarget{UserId: uuid.New(), Username: "jane", Muted: true}
+	viewing := types.NotificationTarget{UserId: uuid.New(), Username: "kim"}
+	blocker := types.NotificationTarget{UserId: uuid.New(), Username: "lee"}
+
+	targets := []types.NotificationTarget{away, muted, viewing, blocker}
+	inRoom := map[string]struct{}{viewing.UserId.String(): {}}
+	blockerIDs := []uuid.UUID{blocker.UserId}
+
+	tests := []struct {
+		name     string
+		mentions []string
+		want     map[string]bool
+	}{
+		{
+			name:     "unread",
+			mentions: nil,
+			want:     map[string]bool{away.UserId.String(): false},
+		},
+		{
+			name:     "mentions",
+			mentions: []string{"aaron", "jane", "kim", "lee"},
+			want:     map[string]bool{away.UserId.String(): true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, messageNotifications(targets, inRoom, blockerIDs, tt.mentions))
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package protocol
+
+import (
+	"encoding/json"
+	"log/slog"
+	"sync"
+
+	"github.com/aaronkim218/eventsocket"
+)
+
+// Socket is the underlying websocket connection
+type Socket interface {
+	ReadMessage() (int, []byte, error)
+	WriteJSON(v any) error
+	Close() error
+}
+
+// Conn validates the frames of a client against its negotiated version
+type Conn struct {
+	socket  Socket
+	version Version
+	logger  *slog.Logger
+	onFrame func()
+	// mu serializes writes and Close
+	mu     sync.Mutex
+	closed bool
+}
+
+type ConnConfig struct {
+	Socket  Socket
+	Version Version
+	Logger  *slog.Logger
+	// OnFrame is called for every valid inbound frame
+	OnFrame func()
+}
+
+func NewConn(cfg *ConnConfig) *Conn {
+	return &Conn{
+		socket:  cfg.Socket,
+		version: cfg.Version,
+		logger:  cfg.Logger,
+		onFrame: cfg.OnFrame,
+	}
+}
+
+func (c *Conn) ReadJSON(v any) error {
+	for {
+		_, data, err := c.socket.ReadMessage()
+		if err != nil {
+			return err
+		}
+
+		var frame eventsocket.Message
+		if err := json.Unmarshal(data, &frame); err != nil || frame.Type == "" {
+			c.WriteError(&Error{
+				Code:    ErrorCodeMalformedFrame,
+				Message: "frame must be a json object with a type",
+			})
+			continue
+		}
+
+		if protocolErr := ValidateFrame(c.version, frame); protocolErr != nil {
+			c.WriteError(protocolErr)
+			continue
+		}
+
+		if c.onFrame != nil {
+			c.onFrame()
+		}
+
+		return json.Unmarshal(data, v)
+	}
+}
+
+func (c *Conn) WriteJSON(v any) error {
+	if frame, ok := v.(eventsocket.Message); ok && !SupportsOutbound(c.version, frame.Type) {
+		c.logger.Warn("Withheld event not defined for protocol version",
+			slog.String("type", frame.Type),
+			slog.Int("version", int(c.version)),
+		)
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil
+	}
+
+	// eventsocket v0.1.4 keeps its lock held if RemoveClient runs twice, so a failed write closes the socket instead
+	if err := c.socket.WriteJSON(v); err != nil {
+		c.logger.Debug("Closing connection after failed write", slog.String("err", err.Error()))
+		c.closeLocked()
+	}
+
+	return nil
+}
+
+// WriteError sends an ERROR frame to the client
+func (c *Conn) WriteError(protocolErr *Error) {
+	frame, err := NewFrame(ErrorEvent, protocolErr)
+	if err == nil {
+		err = c.WriteJSON(frame)
+	}
+
+	if err != nil {
+		c.logger.Error("Failed to send protocol error",
+			slog.String("err", err.Error()),
+			slog.String("code", string(protocolErr.Code)),
+			slog.String("type", protocolErr.Event),
+		)
+	}
+}
+
+// Close closes the socket, which ends the read loop and removes the client
+func (c *Conn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.closeLocked()
+}
+
+func (c *Conn) closeLocked() error {
+	if c.closed {
+		return nil
+	}
+
+	c.closed = true
+	return c.socket.Close()
+}
@@ -0,0 +1,26 @@
+package protocol
+
+type ErrorCode string
+
+const (
+	ErrorCodeMalformedFrame     ErrorCode = "malformed_frame"
+	ErrorCodeUnknownEvent       ErrorCode = "unknown_event"
+	ErrorCodeInvalidPayload     ErrorCode = "invalid_payload"
+	ErrorCodeUnsupportedVersion ErrorCode = "unsupported_version"
+	ErrorCodeUnauthorized       ErrorCode = "unauthorized"
+	ErrorCodeProfileNotFound    ErrorCode = "profile_not_found"
+	ErrorCodeAlreadyConnected   ErrorCode = "already_connected"
+	ErrorCodeInternal           ErrorCode = "internal_error"
+)
+
+// Error is the payload of an ERROR frame
+type Error struct {
+	Code    ErrorCode         `json:"code"`
+	Event   string            `json:"event,omitempty"`
+	Message string            `json:"message"`
+	Errors  map[string]string `json:"errors,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return string(e.Code) + ": " + e.Message
+}
@@ -0,0 +1,77 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// Hello is the first frame of a connection
+type Hello struct {
+	Token    string    `json:"token"`
+	Versions []Version `json:"versions"`
+	// Legacy marks clients that predate the handshake and send the bare token
+	Legacy bool `json:"-"`
+}
+
+func (h *Hello) Validate() map[string]string {
+	errMap := make(map[string]string)
+
+	if strings.TrimSpace(h.Token) == "" {
+		errMap["token"] = "token is required"
+	}
+
+	if len(h.Versions) == 0 {
+		errMap["versions"] = "at least one version must be offered"
+	}
+
+	return errMap
+}
+
+// Welcome acknowledges the handshake with the negotiated version
+type Welcome struct {
+	Version      Version `json:"version"`
+	ConnectionId string  `json:"connection_id"`
+}
+
+// ParseHello reads the first frame, taking anything but a json object as a bare version 1 token
+func ParseHello(data []byte) (Hello, *Error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return Hello{
+			Token:    string(trimmed),
+			Versions: []Version{Version1},
+			Legacy:   true,
+		}, nil
+	}
+
+	var frame struct {
+		Type string `json:"type"`
+		Data Hello  `json:"data"`
+	}
+	if err := json.Unmarshal(trimmed, &frame); err != nil {
+		return Hello{}, &Error{
+			Code:    ErrorCodeMalformedFrame,
+			Message: "frame must be a json object with a type",
+		}
+	}
+
+	if frame.Type != HelloEvent {
+		return Hello{}, &Error{
+			Code:    ErrorCodeUnknownEvent,
+			Event:   frame.Type,
+			Message: "the first frame must be HELLO",
+		}
+	}
+
+	if errMap := frame.Data.Validate(); len(errMap) > 0 {
+		return Hello{}, &Error{
+			Code:    ErrorCodeInvalidPayload,
+			Event:   HelloEvent,
+			Message: "invalid payload",
+			Errors:  errMap,
+		}
+	}
+
+	return frame.Data, nil
+}
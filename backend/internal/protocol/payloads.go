@@ -0,0 +1,73 @@
+package protocol
+
+import (
+	"strings"
+
+	"go-chat/internal/types"
+
+	"github.com/google/uuid"
+)
+
+// Payload is the data of an inbound event
+type Payload interface {
+	Validate() map[string]string
+}
+
+type JoinRoom struct {
+	RoomID string `json:"room_id"`
+}
+
+func (jr *JoinRoom) Validate() map[string]string {
+	return validateRoomID(jr.RoomID)
+}
+
+type LeaveRoom struct {
+	RoomID string `json:"room_id"`
+}
+
+func (lr *LeaveRoom) Validate() map[string]string {
+	return validateRoomID(lr.RoomID)
+}
+
+type UserMessage struct {
+	RoomID  string `json:"room_id"`
+	Content string `json:"content"`
+}
+
+func (um *UserMessage) Validate() map[string]string {
+	errMap := validateRoomID(um.RoomID)
+
+	if strings.TrimSpace(um.Content) == "" {
+		errMap["content"] = "content cannot be empty"
+	}
+
+	return errMap
+}
+
+// TypingStatus is sent while the user types, any profile older clients send is ignored
+type TypingStatus struct {
+	RoomID string `json:"room_id"`
+}
+
+func (ts *TypingStatus) Validate() map[string]string {
+	return validateRoomID(ts.RoomID)
+}
+
+type SetStatus = types.StatusUpdate
+
+// Activity tells the server the user is active, it carries no data
+type Activity struct{}
+
+func (a *Activity) Validate() map[string]string {
+	return make(map[string]string)
+}
+
+func validateRoomID(roomID string) map[string]string {
+	errMap := make(map[string]string)
+
+	if _, err := uuid.Parse(roomID); err != nil {
+		errMap["room_id"] = "room_id must be a valid uuid"
+	}
+
+	return errMap
+}
@@ -0,0 +1,160 @@
+package protocol
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/aaronkim218/eventsocket"
+)
+
+type Version int
+
+const (
+	Version1 Version = 1
+)
+
+// supportedVersions lists every version the server speaks, oldest first
+var supportedVersions = []Version{Version1}
+
+// inbound events
+const (
+	HelloEvent        = "HELLO"
+	JoinRoomEvent     = "JOIN_ROOM"
+	LeaveRoomEvent    = "LEAVE_ROOM"
+	UserMessageEvent  = "USER_MESSAGE"
+	TypingStatusEvent = "TYPING_STATUS"
+	SetStatusEvent    = "SET_STATUS"
+	ActivityEvent     = "ACTIVITY"
+)
+
+// outbound events, USER_MESSAGE and TYPING_STATUS go both ways
+const (
+	WelcomeEvent             = "WELCOME"
+	ErrorEvent               = "ERROR"
+	JoinRoomSuccessEvent     = "JOIN_ROOM_SUCCESS"
+	JoinRoomErrorEvent       = "JOIN_ROOM_ERROR"
+	UserMessageErrorEvent    = "USER_MESSAGE_ERROR"
+	PresenceEvent            = "PRESENCE"
+	PresenceStatusEvent      = "PRESENCE_STATUS"
+	UserPresenceEvent        = "USER_PRESENCE"
+	ProfileUpdatedEvent      = "PROFILE_UPDATED"
+	MemberJoinedEvent        = "MEMBER_JOINED"
+	RoomInvitationEvent      = "ROOM_INVITATION"
+	RoomUpdatedEvent         = "ROOM_UPDATED"
+	MemberBannedEvent        = "MEMBER_BANNED"
+	MemberUnbannedEvent      = "MEMBER_UNBANNED"
+	MemberMutedEvent         = "MEMBER_MUTED"
+	MemberUnmutedEvent       = "MEMBER_UNMUTED"
+	ContactRequestEvent      = "CONTACT_REQUEST"
+	ContactAcceptedEvent     = "CONTACT_ACCEPTED"
+	AccountDeletedEvent      = "ACCOUNT_DELETED"
+	PreferencesUpdatedEvent  = "PREFERENCES_UPDATED"
+	MessageNotificationEvent = "MESSAGE_NOTIFICATION"
+)
+
+type inboundEvent struct {
+	since   Version
+	payload func() Payload
+}
+
+// inboundEvents are the events a client may send once the handshake is done
+var inboundEvents = map[string]inboundEvent{
+	JoinRoomEvent:     {since: Version1, payload: func() Payload { return &JoinRoom{} }},
+	LeaveRoomEvent:    {since: Version1, payload: func() Payload { return &LeaveRoom{} }},
+	UserMessageEvent:  {since: Version1, payload: func() Payload { return &UserMessage{} }},
+	TypingStatusEvent: {since: Version1, payload: func() Payload { return &TypingStatus{} }},
+	SetStatusEvent:    {since: Version1, payload: func() Payload { return &SetStatus{} }},
+	ActivityEvent:     {since: Version1, payload: func() Payload { return &Activity{} }},
+}
+
+// outboundEvents maps the events the server may send to the version that introduced them
+var outboundEvents = map[string]Version{
+	WelcomeEvent:             Version1,
+	ErrorEvent:               Version1,
+	JoinRoomSuccessEvent:     Version1,
+	JoinRoomErrorEvent:       Version1,
+	UserMessageEvent:         Version1,
+	UserMessageErrorEvent:    Version1,
+	TypingStatusEvent:        Version1,
+	PresenceEvent:            Version1,
+	PresenceStatusEvent:      Version1,
+	UserPresenceEvent:        Version1,
+	ProfileUpdatedEvent:      Version1,
+	MemberJoinedEvent:        Version1,
+	RoomInvitationEvent:      Version1,
+	RoomUpdatedEvent:         Version1,
+	MemberBannedEvent:        Version1,
+	MemberUnbannedEvent:      Version1,
+	MemberMutedEvent:         Version1,
+	MemberUnmutedEvent:       Version1,
+	ContactRequestEvent:      Version1,
+	ContactAcceptedEvent:     Version1,
+	AccountDeletedEvent:      Version1,
+	PreferencesUpdatedEvent:  Version1,
+	MessageNotificationEvent: Version1,
+}
+
+// Negotiate picks the newest version both the client and the server speak
+func Negotiate(clientVersions []Version) (Version, bool) {
+	for _, version := range slices.Backward(supportedVersions) {
+		if slices.Contains(clientVersions, version) {
+			return version, true
+		}
+	}
+
+	return 0, false
+}
+
+// SupportsOutbound reports whether the server may send the event to a client speaking the version
+func SupportsOutbound(version Version, eventType string) bool {
+	since, known := outboundEvents[eventType]
+	return known && version >= since
+}
+
+// ValidateFrame returns the error to send back for an invalid inbound frame
+func ValidateFrame(version Version, frame eventsocket.Message) *Error {
+	event, known := inboundEvents[frame.Type]
+	if !known || version < event.since {
+		return &Error{
+			Code:    ErrorCodeUnknownEvent,
+			Event:   frame.Type,
+			Message: fmt.Sprintf("unknown event for protocol version %d", version),
+		}
+	}
+
+	payload := event.payload()
+	if len(frame.Data) > 0 {
+		if err := json.Unmarshal(frame.Data, payload); err != nil {
+			return &Error{
+				Code:    ErrorCodeInvalidPayload,
+				Event:   frame.Type,
+				Message: "payload does not match the event schema",
+			}
+		}
+	}
+
+	if errMap := payload.Validate(); len(errMap) > 0 {
+		return &Error{
+			Code:    ErrorCodeInvalidPayload,
+			Event:   frame.Type,
+			Message: "invalid payload",
+			Errors:  errMap,
+		}
+	}
+
+	return nil
+}
+
+// NewFrame encodes the payload as a frame of the event
+func NewFrame(eventType string, payload any) (eventsocket.Message, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return eventsocket.Message{}, err
+	}
+
+	return eventsocket.Message{
+		Type: eventType,
+		Data: data,
+	}, nil
+}
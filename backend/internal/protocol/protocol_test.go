@@ -0,0 +1,205 @@
+package protocol
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/aaronkim218/eventsocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name        string
+		offered     []Version
+		wantVersion Version
+		wantOk      bool
+	}{
+		{name: "supported version", offered: []Version{Version1}, wantVersion: Version1, wantOk: true},
+		{name: "newer and supported versions", offered: []Version{Version1, 99}, wantVersion: Version1, wantOk: true},
+		{name: "only unsupported versions", offered: []Version{99}, wantOk: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, ok := Negotiate(tt.offered)
+
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.wantVersion, version)
+		})
+	}
+}
+
+func TestValidateFrame(t *testing.T) {
+	tests := []struct {
+		name    string
+		frame   eventsocket.Message
+		wantErr *Error
+	}{
+		{
+			name:  "valid frame",
+			frame: eventsocket.Message{Type: JoinRoomEvent, Data: json.RawMessage(`{"room_id": "6f1c3c1e-3a55-4a8e-9a52-1d2a0c1e4b7f"}`)},
+		},
+		{
+			name:  "frame without data",
+			frame: eventsocket.Message{Type: ActivityEvent},
+		},
+		{
+			name:    "unknown event",
+			frame:   eventsocket.Message{Type: "SHOUT"},
+			wantErr: &Error{Code: ErrorCodeUnknownEvent, Event: "SHOUT", Message: "unknown event for protocol version 1"},
+		},
+		{
+			name:    "payload of the wrong shape",
+			frame:   eventsocket.Message{Type: UserMessageEvent, Data: json.RawMessage(`["hello"]`)},
+			wantErr: &Error{Code: ErrorCodeInvalidPayload, Event: UserMessageEvent, Message: "payload does not match the event schema"},
+		},
+		{
+			name:  "invalid payload",
+			frame: eventsocket.Message{Type: UserMessageEvent, Data: json.RawMessage(`{"room_id": "lobby", "content": " "}`)},
+			wantErr: &Error{
+				Code:    ErrorCodeInvalidPayload,
+				Event:   UserMessageEvent,
+				Message: "invalid payload",
+				Errors: map[string]string{
+					"room_id": "room_id must be a valid uuid",
+					"content": "content cannot be empty",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantErr, ValidateFrame(Version1, tt.frame))
+		})
+	}
+}
+
+func TestParseHello(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantHello Hello
+		wantErr   *Error
+	}{
+		{
+			name:      "bare token",
+			input:     "header.payload.signature",
+			wantHello: Hello{Token: "header.payload.signature", Versions: []Version{Version1}, Legacy: true},
+		},
+		{
+			name:      "hello frame",
+			input:     `{"type": "HELLO", "data": {"token": "header.payload.signature", "versions": [1, 2]}}`,
+			wantHello: Hello{Token: "header.payload.signature", Versions: []Version{Version1, 2}},
+		},
+		{
+			name:    "other first frame",
+			input:   `{"type": "JOIN_ROOM", "data": {}}`,
+			wantErr: &Error{Code: ErrorCodeUnknownEvent, Event: JoinRoomEvent, Message: "the first frame must be HELLO"},
+		},
+		{
+			name:  "hello without versions",
+			input: `{"type": "HELLO", "data": {"token": "header.payload.signature"}}`,
+			wantErr: &Error{
+				Code:    ErrorCodeInvalidPayload,
+				Event:   HelloEvent,
+				Message: "invalid payload",
+				Errors:  map[string]string{"versions": "at least one version must be offered"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hello, err := ParseHello([]byte(tt.input))
+
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantHello, hello)
+		})
+	}
+}
+
+type fakeSocket struct {
+	inbound  []string
+	outbound []any
+	writeErr error
+	closes   int
+}
+
+func (fs *fakeSocket) ReadMessage() (int, []byte, error) {
+	if len(fs.inbound) == 0 {
+		return 0, nil, io.EOF
+	}
+
+	data := fs.inbound[0]
+	fs.inbound = fs.inbound[1:]
+	return 1, []byte(data), nil
+}
+
+func (fs *fakeSocket) WriteJSON(v any) error {
+	if fs.writeErr != nil {
+		return fs.writeErr
+	}
+
+	fs.outbound = append(fs.outbound, v)
+	return nil
+}
+
+func (fs *fakeSocket) Close() error {
+	fs.closes++
+	return nil
+}
+
+func TestConn(t *testing.T) {
+	socket := &fakeSocket{
+		inbound: []string{
+			`not json`,
+			`{"type": "SHOUT", "data": {}}`,
+			`{"type": "LEAVE_ROOM", "data": {"room_id": "6f1c3c1e-3a55-4a8e-9a52-1d2a0c1e4b7f"}}`,
+		},
+	}
+	conn := NewConn(&ConnConfig{
+		Socket:  socket,
+		Version: Version1,
+		Logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+
+	var msg eventsocket.Message
+	assert.NoError(t, conn.ReadJSON(&msg))
+	assert.Equal(t, LeaveRoomEvent, msg.Type)
+	assert.True(t, errors.Is(conn.ReadJSON(&msg), io.EOF))
+
+	var codes []ErrorCode
+	for _, out := range socket.outbound {
+		frame := out.(eventsocket.Message)
+		assert.Equal(t, ErrorEvent, frame.Type)
+
+		var protocolErr Error
+		assert.NoError(t, json.Unmarshal(frame.Data, &protocolErr))
+		codes = append(codes, protocolErr.Code)
+	}
+	assert.Equal(t, []ErrorCode{ErrorCodeMalformedFrame, ErrorCodeUnknownEvent}, codes)
+
+	assert.NoError(t, conn.WriteJSON(eventsocket.Message{Type: "UNDECLARED"}))
+	assert.Len(t, socket.outbound, 2, "undeclared events are withheld")
+}
+
+func TestConn_WriteFailureClosesSocket(t *testing.T) {
+	socket := &fakeSocket{writeErr: errors.New("broken pipe")}
+	conn := NewConn(&ConnConfig{
+		Socket:  socket,
+		Version: Version1,
+		Logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+
+	assert.NoError(t, conn.WriteJSON(eventsocket.Message{Type: ErrorEvent}), "write errors are not returned to eventsocket")
+	assert.Equal(t, 1, socket.closes)
+
+	assert.NoError(t, conn.WriteJSON(eventsocket.Message{Type: ErrorEvent}))
+	assert.NoError(t, conn.Close())
+	assert.Equal(t, 1, socket.closes, "the socket is closed once")
+}
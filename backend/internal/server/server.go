@@ -7,6 +7,7 @@ import (
 	"go-chat/internal/handlers"
 	"go-chat/internal/plugins"
 	"go-chat/internal/storage"
+	"go-chat/internal/usernames"
 	"go-chat/internal/xerrors"
 
 	"github.com/aaronkim218/eventsocket"
@@ -26,6 +27,7 @@ type Config struct {
 	FiberStorage     fiber.Storage
 	Eventsocket      *eventsocket.Eventsocket
 	PluginsContainer *plugins.Container
+	UsernamePolicy   *usernames.Policy
 }
 
 func New(cfg *Config) *fiber.App {
@@ -39,6 +41,7 @@ func New(cfg *Config) *fiber.App {
 		FiberStorage:     cfg.FiberStorage,
 		Eventsocket:      cfg.Eventsocket,
 		PluginsContainer: cfg.PluginsContainer,
+		UsernamePolicy:   cfg.UsernamePolicy,
 	})
 	setupMiddleware(app)
 	service.RegisterRoutes(app)
@@ -3,11 +3,12 @@ package settings
 import "github.com/caarlos0/env/v11"
 
 type Settings struct {
-	Storage Storage `envPrefix:"STORAGE_"`
-	Server  Server  `envPrefix:"SERVER_"`
-	Jwt     Jwt     `envPrefix:"JWT_"`
-	Log     Log     `envPrefix:"LOG_"`
-	Hub     Hub     `envPrefix:"HUB_"`
+	Storage  Storage  `envPrefix:"STORAGE_"`
+	Server   Server   `envPrefix:"SERVER_"`
+	Jwt      Jwt      `envPrefix:"JWT_"`
+	Log      Log      `envPrefix:"LOG_"`
+	Hub      Hub      `envPrefix:"HUB_"`
+	Username Username `envPrefix:"USERNAME_"`
 }
 
 func Load() (Settings, error) {
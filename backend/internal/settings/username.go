@@ -0,0 +1,6 @@
+package settings
+
+type Username struct {
+	AllowedPattern string   `env:"ALLOWED_PATTERN" envDefault:"^[A-Za-z0-9](?:[A-Za-z0-9_.-]*[A-Za-z0-9])?$"`
+	Reserved       []string `env:"RESERVED" envDefault:"admin,administrator,root,system,support,moderator,staff,help,api,null,undefined,everyone,here,deleted"`
+}
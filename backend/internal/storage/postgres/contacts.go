@@ -0,0 +1,296 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go-chat/internal/constants"
+	"go-chat/internal/models"
+	"go-chat/internal/types"
+	"go-chat/internal/utils"
+	"go-chat/internal/xerrors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const contactColumns = "requester, recipient, status, created_at, accepted_at, declined_at"
+
+// CreateContactRequest sends a contact request to the recipient. If the recipient has already sent the requester a
+// request it is accepted instead. A request the recipient declined can only be sent again once the cooldown has passed,
+// while the recipient can always reach out in the other direction. Requests between users where either has blocked the
+// other are reported as a missing profile.
+func (p *Postgres) CreateContactRequest(ctx context.Context, contact models.Contact, cooldown time.Duration) (models.Contact, error) {
+	const acceptQuery string = `
+	UPDATE contacts SET status = 'accepted', accepted_at = $3
+	WHERE requester = $2 AND recipient = $1 AND status = 'pending'
+	RETURNING ` + contactColumns
+	const reopenQuery string = `
+	UPDATE contacts SET requester = $1, recipient = $2, status = 'pending', created_at = $3, declined_at = NULL
+	WHERE LEAST(requester, recipient) = LEAST($1::UUID, $2::UUID)
+	  AND GREATEST(requester, recipient) = GREATEST($1::UUID, $2::UUID)
+	  AND status = 'declined'
+	  AND (requester = $2 OR declined_at <= $4)
+	  AND NOT EXISTS (
+		SELECT 1 FROM user_blocks
+		WHERE (blocker = $1 AND blocked = $2) OR (blocker = $2 AND blocked = $1)
+	  )
+	RETURNING ` + contactColumns
+	const declinedQuery string = `SELECT 1 FROM contacts WHERE requester = $1 AND recipient = $2 AND status = 'declined'`
+	const insertQuery string = `
+	INSERT INTO contacts (requester, recipient, status, created_at)
+	SELECT $1, $2, 'pending', $3
+	WHERE NOT EXISTS (
+		SELECT 1 FROM user_blocks
+		WHERE (blocker = $1 AND blocked = $2) OR (blocker = $2 AND blocked = $1)
+	)
+	RETURNING ` + contactColumns
+
+	return utils.Retry(ctx, func(ctx context.Context) (models.Contact, error) {
+		tx, err := p.Pool.Begin(ctx)
+		if err != nil {
+			return models.Contact{}, err
+		}
+		defer func() { _ = tx.Rollback(ctx) }()
+
+		rows, err := tx.Query(ctx, acceptQuery, contact.Requester, contact.Recipient, contact.CreatedAt)
+		if err != nil {
+			return models.Contact{}, err
+		}
+
+		accepted, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.Contact])
+		if err == nil {
+			return accepted, tx.Commit(ctx)
+		}
+
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return models.Contact{}, err
+		}
+
+		rows, err = tx.Query(ctx, reopenQuery, contact.Requester, contact.Recipient, contact.CreatedAt, contact.CreatedAt.Add(-cooldown))
+		if err != nil {
+			return models.Contact{}, err
+		}
+
+		reopened, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.Contact])
+		if err == nil {
+			return reopened, tx.Commit(ctx)
+		}
+
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return models.Contact{}, err
+		}
+
+		var declined int
+		if err := tx.QueryRow(ctx, declinedQuery, contact.Requester, contact.Recipient).Scan(&declined); err == nil {
+			return models.Contact{}, utils.CreateNonRetryableError(xerrors.ContactRequestDeclinedError())
+		} else if !errors.Is(err, pgx.ErrNoRows) {
+			return models.Contact{}, err
+		}
+
+		rows, err = tx.Query(ctx, insertQuery, contact.Requester, contact.Recipient, contact.CreatedAt)
+		if err != nil {
+			return models.Contact{}, err
+		}
+
+		created, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.Contact])
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) || xerrors.IsForeignKeyViolation(err, constants.ContactsRecipientFKeyConstraint) {
+				return models.Contact{}, utils.CreateNonRetryableError(xerrors.NotFoundError("profile", map[string]string{
+					"user_id": contact.Recipient.String(),
+				}))
+			}
+
+			if xerrors.IsUniqueViolation(err, constants.ContactsPKeyUniqueConstraint) ||
+				xerrors.IsUniqueViolation(err, constants.ContactsPairUniqueConstraint) {
+				return models.Contact{}, utils.CreateNonRetryableError(xerrors.ConflictError("contact", "user_id", contact.Recipient.String()))
+			}
+
+			return models.Contact{}, err
+		}
+
+		return created, tx.Commit(ctx)
+	})
+}
+
+func (p *Postgres) AcceptContactRequest(ctx context.Context, requesterId uuid.UUID, recipientId uuid.UUID) (models.Contact, error) {
+	const query string = `
+	UPDATE contacts SET status = 'accepted', accepted_at = now()
+	WHERE requester = $1 AND recipient = $2 AND status = 'pending'
+	RETURNING ` + contactColumns
+
+	rows, err := utils.Retry(ctx, func(ctx context.Context) (pgx.Rows, error) {
+		return p.Pool.Query(ctx, query, requesterId, recipientId)
+	})
+	if err != nil {
+		return models.Contact{}, err
+	}
+
+	contact, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.Contact])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Contact{}, xerrors.NotFoundError("contact request", map[string]string{
+				"requester": requesterId.String(),
+				"recipient": recipientId.String(),
+			})
+		}
+
+		return models.Contact{}, err
+	}
+
+	return contact, nil
+}
+
+// DeleteContactRequest closes a pending request between the users. The recipient declines it, which keeps the row so
+// the requester is held to the cooldown before asking again, and the requester cancels it, which removes the row.
+func (p *Postgres) DeleteContactRequest(ctx context.Context, userId uuid.UUID, otherId uuid.UUID, declinedAt time.Time) error {
+	const declineQuery string = `
+	UPDATE contacts SET status = 'declined', declined_at = $3
+	WHERE requester = $2 AND recipient = $1 AND status = 'pending'
+	`
+	const cancelQuery string = `DELETE FROM contacts WHERE requester = $1 AND recipient = $2 AND status = 'pending'`
+
+	_, err := utils.Retry(ctx, func(ctx context.Context) (struct{}, error) {
+		ct, err := p.Pool.Exec(ctx, declineQuery, userId, otherId, declinedAt)
+		if err != nil {
+			return struct{}{}, err
+		}
+
+		if ct.RowsAffected() > 0 {
+			return struct{}{}, nil
+		}
+
+		ct, err = p.Pool.Exec(ctx, cancelQuery, userId, otherId)
+		if err != nil {
+			return struct{}{}, err
+		}
+
+		if ct.RowsAffected() == 0 {
+			return struct{}{}, utils.CreateNonRetryableError(xerrors.NotFoundError("contact request", map[string]string{
+				"user_id": otherId.String(),
+			}))
+		}
+
+		return struct{}{}, nil
+	})
+
+	return err
+}
+
+func (p *Postgres) DeleteContact(ctx context.Context, userId uuid.UUID, contactId uuid.UUID) error {
+	const query string = `
+	DELETE FROM contacts
+	WHERE status = 'accepted'
+	  AND ((requester = $1 AND recipient = $2) OR (requester = $2 AND recipient = $1))
+	`
+
+	_, err := utils.Retry(ctx, func(ctx context.Context) (struct{}, error) {
+		ct, err := p.Pool.Exec(ctx, query, userId, contactId)
+		if err != nil {
+			return struct{}{}, err
+		}
+
+		if ct.RowsAffected() == 0 {
+			return struct{}{}, utils.CreateNonRetryableError(xerrors.NotFoundError("contact", map[string]string{
+				"user_id": contactId.String(),
+			}))
+		}
+
+		return struct{}{}, nil
+	})
+
+	return err
+}
+
+func (p *Postgres) GetContactProfiles(ctx context.Context, userId uuid.UUID) ([]models.Profile, error) {
+	const query string = `
+	SELECT ` + prefixedProfileColumns + `
+	FROM contacts AS c
+	INNER JOIN profiles AS p ON p.user_id = CASE WHEN c.requester = $1 THEN c.recipient ELSE c.requester END
+	WHERE (c.requester = $1 OR c.recipient = $1) AND c.status = 'accepted'
+	ORDER BY p.username ASC
+	`
+
+	rows, err := utils.Retry(ctx, func(ctx context.Context) (pgx.Rows, error) {
+		return p.Pool.Query(ctx, query, userId)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	profiles, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.Profile, error) {
+		profile, err := pgx.RowToStructByName[models.Profile](row)
+		if err != nil {
+			return models.Profile{}, err
+		}
+
+		return profile, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return profiles, nil
+}
+
+// GetContactRequests returns the user's pending contact requests in both directions
+func (p *Postgres) GetContactRequests(ctx context.Context, userId uuid.UUID) ([]types.ContactRequest, error) {
+	const query string = `
+	SELECT
+	    c.requester,
+	    c.recipient,
+	    c.status,
+	    c.created_at,
+	    c.accepted_at,
+	    c.declined_at,
+	    CASE WHEN c.recipient = $1 THEN 'incoming' ELSE 'outgoing' END AS direction,
+	    to_jsonb(p) AS profile
+	FROM contacts AS c
+	INNER JOIN profiles AS p ON p.user_id = CASE WHEN c.requester = $1 THEN c.recipient ELSE c.requester END
+	WHERE (c.requester = $1 OR c.recipient = $1) AND c.status = 'pending'
+	ORDER BY c.created_at DESC
+	`
+
+	rows, err := utils.Retry(ctx, func(ctx context.Context) (pgx.Rows, error) {
+		return p.Pool.Query(ctx, query, userId)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	requests, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (types.ContactRequest, error) {
+		request, err := pgx.RowToStructByName[types.ContactRequest](row)
+		if err != nil {
+			return types.ContactRequest{}, err
+		}
+
+		return request, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return requests, nil
+}
+
+func (p *Postgres) CheckUsersAreContacts(ctx context.Context, userId uuid.UUID, otherId uuid.UUID) (bool, error) {
+	const query string = `
+	SELECT 1 FROM contacts
+	WHERE status = 'accepted'
+	  AND ((requester = $1 AND recipient = $2) OR (requester = $2 AND recipient = $1))
+	`
+
+	var exists int
+	return utils.Retry(ctx, func(ctx context.Context) (bool, error) {
+		if err := p.Pool.QueryRow(ctx, query, userId, otherId).Scan(&exists); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return false, nil
+			}
+
+			return false, err
+		}
+
+		return true, nil
+	})
+}
@@ -0,0 +1,103 @@
+package postgres
+
+import (
+	"bytes"
+	"context"
+	"errors"
+
+	"go-chat/internal/constants"
+	"go-chat/internal/models"
+	"go-chat/internal/types"
+	"go-chat/internal/utils"
+	"go-chat/internal/xerrors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+func (p *Postgres) GetOrCreateDmRoom(ctx context.Context, room models.Room, userId uuid.UUID, peerId uuid.UUID) (types.UserRoom, bool, error) {
+	const selectQuery string = `SELECT room_id FROM dms WHERE user_a = $1 AND user_b = $2`
+	const roomsQuery string = `INSERT INTO rooms (id, host, name, kind, visibility, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	const dmsQuery string = `INSERT INTO dms (room_id, user_a, user_b) VALUES ($1, $2, $3) ON CONFLICT (user_a, user_b) DO NOTHING`
+	const usersRoomsQuery string = `INSERT INTO users_rooms (user_id, room_id) VALUES ($1, $2), ($3, $2)`
+
+	userA, userB := orderDmPair(userId, peerId)
+
+	type result struct {
+		roomId  uuid.UUID
+		created bool
+	}
+
+	res, err := utils.Retry(ctx, func(ctx context.Context) (result, error) {
+		var existingId uuid.UUID
+		if err := p.Pool.QueryRow(ctx, selectQuery, userA, userB).Scan(&existingId); err == nil {
+			return result{roomId: existingId}, nil
+		} else if !errors.Is(err, pgx.ErrNoRows) {
+			return result{}, err
+		}
+
+		tx, err := p.Pool.Begin(ctx)
+		if err != nil {
+			return result{}, err
+		}
+		defer func() { _ = tx.Rollback(ctx) }()
+
+		if _, err := tx.Exec(ctx, roomsQuery, room.Id, room.Host, room.Name, room.Kind, room.Visibility, room.CreatedAt, room.UpdatedAt); err != nil {
+			return result{}, err
+		}
+
+		ct, err := tx.Exec(ctx, dmsQuery, room.Id, userA, userB)
+		if err != nil {
+			if xerrors.IsForeignKeyViolation(err, constants.DmsUserAFKeyConstraint) ||
+				xerrors.IsForeignKeyViolation(err, constants.DmsUserBFKeyConstraint) {
+				return result{}, utils.CreateNonRetryableError(xerrors.NotFoundError("profile", map[string]string{
+					"user_id": peerId.String(),
+				}))
+			}
+
+			return result{}, err
+		}
+
+		// another request created the dm first so retry to pick up its room
+		if ct.RowsAffected() == 0 {
+			return result{}, errors.New("dm room created concurrently")
+		}
+
+		if _, err := tx.Exec(ctx, usersRoomsQuery, userA, room.Id, userB); err != nil {
+			return result{}, err
+		}
+
+		if err := tx.Commit(ctx); err != nil {
+			return result{}, err
+		}
+
+		return result{roomId: room.Id, created: true}, nil
+	})
+	if err != nil {
+		return types.UserRoom{}, false, err
+	}
+
+	dmRoom, err := p.GetRoomById(ctx, res.roomId)
+	if err != nil {
+		return types.UserRoom{}, false, err
+	}
+
+	peer, err := p.GetProfileByUserId(ctx, peerId)
+	if err != nil {
+		return types.UserRoom{}, false, err
+	}
+
+	return types.UserRoom{
+		Room: dmRoom,
+		Peer: &peer,
+	}, res.created, nil
+}
+
+// orderDmPair orders two user ids the same way postgres compares uuids so a pair always maps to one dms row
+func orderDmPair(first uuid.UUID, second uuid.UUID) (uuid.UUID, uuid.UUID) {
+	if bytes.Compare(first[:], second[:]) < 0 {
+		return first, second
+	}
+
+	return second, first
+}
@@ -0,0 +1,194 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go-chat/internal/models"
+	"go-chat/internal/types"
+	"go-chat/internal/utils"
+	"go-chat/internal/xerrors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func (p *Postgres) CreateInvitations(ctx context.Context, invitations []models.Invitation) (types.BulkResult[uuid.UUID], error) {
+	// invitees must belong to the room's workspace, must not already be members or banned, must not have blocked or
+	// been blocked by the inviter and must not already have a pending invitation
+	const query string = `
+	INSERT INTO room_invitations (id, room_id, inviter, invitee, status, created_at, updated_at)
+	SELECT $1, $2, $3, $4, $5, $6, $7
+	WHERE EXISTS (
+		SELECT 1
+		FROM workspace_members AS wm
+		INNER JOIN rooms AS r ON wm.workspace_id = r.workspace_id
+		WHERE r.id = $2 AND wm.user_id = $4
+	)
+	AND NOT EXISTS (
+		SELECT 1 FROM users_rooms WHERE user_id = $4 AND room_id = $2
+	)
+	AND NOT EXISTS (
+		SELECT 1 FROM room_bans WHERE user_id = $4 AND room_id = $2
+	)
+	AND NOT EXISTS (
+		SELECT 1 FROM user_blocks
+		WHERE (blocker = $4 AND blocked = $3) OR (blocker = $3 AND blocked = $4)
+	)
+	ON CONFLICT DO NOTHING
+	`
+
+	bulkResult := types.BulkResult[uuid.UUID]{}
+	batch := &pgx.Batch{}
+	for _, invitation := range invitations {
+		batch.Queue(query,
+			invitation.Id,
+			invitation.RoomId,
+			invitation.Inviter,
+			invitation.Invitee,
+			invitation.Status,
+			invitation.CreatedAt,
+			invitation.UpdatedAt,
+		).Exec(func(ct pgconn.CommandTag) error {
+			if ct.RowsAffected() == 0 {
+				bulkResult.Failures = append(bulkResult.Failures, types.Failure[uuid.UUID]{
+					Item:    invitation.Invitee,
+					Message: "failed to invite user to room",
+				})
+			} else {
+				bulkResult.Successes = append(bulkResult.Successes, invitation.Invitee)
+			}
+
+			return nil
+		})
+	}
+
+	results := p.Pool.SendBatch(ctx, batch)
+	if err := results.Close(); err != nil {
+		return types.BulkResult[uuid.UUID]{}, err
+	}
+
+	return bulkResult, nil
+}
+
+func (p *Postgres) GetPendingInvitationsByUserId(ctx context.Context, userId uuid.UUID) ([]types.PendingInvitation, error) {
+	const query string = `
+	SELECT
+	    i.id,
+	    i.room_id,
+	    i.inviter,
+	    i.invitee,
+	    i.status,
+	    i.created_at,
+	    i.updated_at,
+	    r.name AS room_name,
+	    to_jsonb(p) AS inviter_profile
+	FROM room_invitations AS i
+	INNER JOIN rooms AS r ON i.room_id = r.id
+	INNER JOIN profiles AS p ON i.inviter = p.user_id
+	WHERE i.invitee = $1 AND i.status = 'pending'
+	ORDER BY i.created_at DESC
+	`
+
+	rows, err := utils.Retry(ctx, func(ctx context.Context) (pgx.Rows, error) {
+		return p.Pool.Query(ctx, query, userId)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	invitations, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (types.PendingInvitation, error) {
+		invitation, err := pgx.RowToStructByName[types.PendingInvitation](row)
+		if err != nil {
+			return types.PendingInvitation{}, err
+		}
+
+		return invitation, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return invitations, nil
+}
+
+func (p *Postgres) AcceptInvitation(ctx context.Context, invitationId uuid.UUID, userId uuid.UUID, updatedAt time.Time) (models.Invitation, error) {
+	const invitationQuery string = `
+	UPDATE room_invitations SET status = 'accepted', updated_at = $3
+	WHERE id = $1 AND invitee = $2 AND status = 'pending'
+	RETURNING id, room_id, inviter, invitee, status, created_at, updated_at
+	`
+	const archivedQuery string = `SELECT archived_at FROM rooms WHERE id = $1`
+	const usersRoomsQuery string = `INSERT INTO users_rooms (user_id, room_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+
+	return utils.Retry(ctx, func(ctx context.Context) (models.Invitation, error) {
+		tx, err := p.Pool.Begin(ctx)
+		if err != nil {
+			return models.Invitation{}, err
+		}
+		defer func() { _ = tx.Rollback(ctx) }()
+
+		rows, err := tx.Query(ctx, invitationQuery, invitationId, userId, updatedAt)
+		if err != nil {
+			return models.Invitation{}, err
+		}
+
+		invitation, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.Invitation])
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return models.Invitation{}, utils.CreateNonRetryableError(xerrors.NotFoundError("pending invitation", map[string]string{
+					"id":      invitationId.String(),
+					"invitee": userId.String(),
+				}))
+			}
+
+			return models.Invitation{}, err
+		}
+
+		var archivedAt *time.Time
+		if err := tx.QueryRow(ctx, archivedQuery, invitation.RoomId).Scan(&archivedAt); err != nil {
+			return models.Invitation{}, err
+		}
+
+		if archivedAt != nil {
+			return models.Invitation{}, utils.CreateNonRetryableError(xerrors.RoomArchivedError())
+		}
+
+		if _, err := tx.Exec(ctx, usersRoomsQuery, userId, invitation.RoomId); err != nil {
+			return models.Invitation{}, err
+		}
+
+		if err := tx.Commit(ctx); err != nil {
+			return models.Invitation{}, err
+		}
+
+		return invitation, nil
+	})
+}
+
+func (p *Postgres) DeclineInvitation(ctx context.Context, invitationId uuid.UUID, userId uuid.UUID, updatedAt time.Time) error {
+	const query string = `
+	UPDATE room_invitations SET status = 'declined', updated_at = $3
+	WHERE id = $1 AND invitee = $2 AND status = 'pending'
+	`
+
+	_, err := utils.Retry(ctx, func(ctx context.Context) (struct{}, error) {
+		ct, err := p.Pool.Exec(ctx, query, invitationId, userId, updatedAt)
+		if err != nil {
+			return struct{}{}, err
+		}
+
+		if ct.RowsAffected() == 0 {
+			return struct{}{}, utils.CreateNonRetryableError(xerrors.NotFoundError("pending invitation", map[string]string{
+				"id":      invitationId.String(),
+				"invitee": userId.String(),
+			}))
+		}
+
+		return struct{}{}, nil
+	})
+
+	return err
+}
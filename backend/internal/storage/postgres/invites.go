@@ -0,0 +1,176 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go-chat/internal/models"
+	"go-chat/internal/utils"
+	"go-chat/internal/xerrors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+func (p *Postgres) CreateInvite(ctx context.Context, invite models.Invite) error {
+	const query string = `INSERT INTO room_invites (code, room_id, created_by, max_uses, uses, expires_at, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err := utils.Retry(ctx, func(ctx context.Context) (struct{}, error) {
+		_, err := p.Pool.Exec(ctx, query,
+			invite.Code,
+			invite.RoomId,
+			invite.CreatedBy,
+			invite.MaxUses,
+			invite.Uses,
+			invite.ExpiresAt,
+			invite.CreatedAt,
+		)
+
+		return struct{}{}, err
+	})
+
+	return err
+}
+
+func (p *Postgres) GetInvitesByRoomId(ctx context.Context, roomId uuid.UUID) ([]models.Invite, error) {
+	const query string = `
+	SELECT code, room_id, created_by, max_uses, uses, expires_at, created_at
+	FROM room_invites
+	WHERE room_id = $1
+	ORDER BY created_at DESC
+	`
+
+	rows, err := utils.Retry(ctx, func(ctx context.Context) (pgx.Rows, error) {
+		return p.Pool.Query(ctx, query, roomId)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	invites, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.Invite, error) {
+		invite, err := pgx.RowToStructByName[models.Invite](row)
+		if err != nil {
+			return models.Invite{}, err
+		}
+
+		return invite, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return invites, nil
+}
+
+func (p *Postgres) DeleteInvite(ctx context.Context, roomId uuid.UUID, code string) error {
+	const query string = `DELETE FROM room_invites WHERE code = $1 AND room_id = $2`
+
+	_, err := utils.Retry(ctx, func(ctx context.Context) (struct{}, error) {
+		ct, err := p.Pool.Exec(ctx, query, code, roomId)
+		if err != nil {
+			return struct{}{}, err
+		}
+
+		if ct.RowsAffected() == 0 {
+			return struct{}{}, utils.CreateNonRetryableError(xerrors.NotFoundError("invite", map[string]string{
+				"code":    code,
+				"room_id": roomId.String(),
+			}))
+		}
+
+		return struct{}{}, nil
+	})
+
+	return err
+}
+
+func (p *Postgres) RedeemInvite(ctx context.Context, code string, userId uuid.UUID) (models.Room, error) {
+	const inviteQuery string = `
+	SELECT i.room_id, i.max_uses, i.uses, i.expires_at, r.archived_at,
+	    EXISTS (
+	      SELECT 1 FROM workspace_members WHERE workspace_id = r.workspace_id AND user_id = $2
+	    ) AS in_workspace,
+	    EXISTS (
+	      SELECT 1 FROM room_bans WHERE room_id = r.id AND user_id = $2
+	    ) AS banned
+	FROM room_invites AS i
+	INNER JOIN rooms AS r ON i.room_id = r.id
+	WHERE i.code = $1
+	FOR UPDATE OF i
+	`
+	const usersRoomsQuery string = `INSERT INTO users_rooms (user_id, room_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	const usesQuery string = `UPDATE room_invites SET uses = uses + 1 WHERE code = $1`
+
+	roomId, err := utils.Retry(ctx, func(ctx context.Context) (uuid.UUID, error) {
+		tx, err := p.Pool.Begin(ctx)
+		if err != nil {
+			return uuid.UUID{}, err
+		}
+		defer func() { _ = tx.Rollback(ctx) }()
+
+		var (
+			roomId      uuid.UUID
+			maxUses     *int
+			uses        int
+			expiresAt   *time.Time
+			archivedAt  *time.Time
+			inWorkspace bool
+			banned      bool
+		)
+
+		if err := tx.QueryRow(ctx, inviteQuery, code, userId).Scan(&roomId, &maxUses, &uses, &expiresAt, &archivedAt, &inWorkspace, &banned); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return uuid.UUID{}, utils.CreateNonRetryableError(xerrors.NotFoundError("invite", map[string]string{
+					"code": code,
+				}))
+			}
+
+			return uuid.UUID{}, err
+		}
+
+		if archivedAt != nil {
+			return uuid.UUID{}, utils.CreateNonRetryableError(xerrors.RoomArchivedError())
+		}
+
+		if !inWorkspace {
+			return uuid.UUID{}, utils.CreateNonRetryableError(xerrors.ForbiddenError("not a member of the room's workspace"))
+		}
+
+		if banned {
+			return uuid.UUID{}, utils.CreateNonRetryableError(xerrors.RoomBannedError())
+		}
+
+		if expiresAt != nil && !expiresAt.After(time.Now()) {
+			return uuid.UUID{}, utils.CreateNonRetryableError(xerrors.GoneError("invite has expired"))
+		}
+
+		if maxUses != nil && uses >= *maxUses {
+			return uuid.UUID{}, utils.CreateNonRetryableError(xerrors.GoneError("invite has no uses remaining"))
+		}
+
+		ct, err := tx.Exec(ctx, usersRoomsQuery, userId, roomId)
+		if err != nil {
+			return uuid.UUID{}, err
+		}
+
+		if ct.RowsAffected() == 0 {
+			return uuid.UUID{}, utils.CreateNonRetryableError(xerrors.ConflictError("membership", "room_id", roomId.String()))
+		}
+
+		if _, err := tx.Exec(ctx, usesQuery, code); err != nil {
+			return uuid.UUID{}, err
+		}
+
+		if err := tx.Commit(ctx); err != nil {
+			return uuid.UUID{}, err
+		}
+
+		return roomId, nil
+	})
+	if err != nil {
+		return models.Room{}, err
+	}
+
+	return p.GetRoomById(ctx, roomId)
+}
@@ -2,6 +2,8 @@ package postgres
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"go-chat/internal/models"
 	"go-chat/internal/types"
@@ -13,7 +15,7 @@ import (
 )
 
 func (p *Postgres) CreateMessage(ctx context.Context, message models.Message) error {
-	const query string = `INSERT INTO messages (id, room_id, author, content, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`
+	const query string = `INSERT INTO messages (id, room_id, author, content, kind, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`
 
 	_, err := utils.Retry(ctx, func(ctx context.Context) (struct{}, error) {
 		_, err := p.Pool.Exec(ctx, query,
@@ -21,6 +23,7 @@ func (p *Postgres) CreateMessage(ctx context.Context, message models.Message) er
 			message.RoomId,
 			message.Author,
 			message.Content,
+			message.Kind,
 			message.CreatedAt,
 			message.UpdatedAt,
 		)
@@ -34,9 +37,17 @@ func (p *Postgres) CreateMessage(ctx context.Context, message models.Message) er
 func (p *Postgres) GetUserMessagesByRoomId(ctx context.Context, roomId uuid.UUID, userId uuid.UUID) ([]types.UserMessage, error) {
 	// TODO: can i use some kind of table constraint to enforce the existence of user_id room_id pair in users_rooms?
 	const query string = `
-	SELECT m.id, m.room_id, m.author, m.content, m.created_at, m.updated_at, p.username, p.first_name, p.last_name
+	SELECT
+	    m.id, m.room_id, m.author, m.content, m.kind, m.created_at, m.updated_at,
+	    COALESCE(p.username, '') AS username,
+	    COALESCE(p.first_name, '') AS first_name,
+	    COALESCE(p.last_name, '') AS last_name,
+	    COALESCE(p.avatar_url, '') AS avatar_url,
+	    EXISTS (
+	      SELECT 1 FROM user_blocks WHERE blocker = $2 AND blocked = m.author
+	    ) AS author_blocked
 	FROM messages AS m
-	INNER JOIN profiles AS p ON m.author = p.user_id
+	LEFT JOIN profiles AS p ON m.author = p.user_id
 	WHERE room_id = $1
 	  AND EXISTS (
 	    SELECT 1
@@ -68,26 +79,122 @@ func (p *Postgres) GetUserMessagesByRoomId(ctx context.Context, roomId uuid.UUID
 	return userMessages, nil
 }
 
-func (p *Postgres) DeleteMessageById(ctx context.Context, messageId uuid.UUID, userId uuid.UUID) error {
-	const query string = `DELETE FROM messages WHERE id = $1 AND author = $2`
+// DeleteMessageById deletes the user's own message and returns the room it was posted in
+func (p *Postgres) DeleteMessageById(ctx context.Context, messageId uuid.UUID, userId uuid.UUID) (uuid.UUID, error) {
+	const query string = `
+	DELETE FROM messages
+	WHERE id = $1 AND author = $2
+	  AND room_id IN (
+	    SELECT id FROM rooms WHERE archived_at IS NULL
+	  )
+	RETURNING room_id
+	`
 
-	_, err := utils.Retry(ctx, func(ctx context.Context) (struct{}, error) {
-		ct, err := p.Pool.Exec(ctx, query, messageId, userId)
+	return utils.Retry(ctx, func(ctx context.Context) (uuid.UUID, error) {
+		var roomId uuid.UUID
+		if err := p.Pool.QueryRow(ctx, query, messageId, userId).Scan(&roomId); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return uuid.UUID{}, utils.CreateNonRetryableError(
+					xerrors.NotFoundError("message", map[string]string{
+						"id":     messageId.String(),
+						"author": userId.String(),
+					}),
+				)
+			}
+
+			return uuid.UUID{}, err
+		}
+
+		return roomId, nil
+	})
+}
+
+// CreateUserMessage stores a message the user posted. When slowMode is positive the message is only stored if the
+// user has not posted in the room within that interval, otherwise the time they may post again is returned. The
+// user's membership row is locked while checking so concurrent messages cannot both slip through.
+func (p *Postgres) CreateUserMessage(ctx context.Context, message models.Message, slowMode time.Duration) (*time.Time, error) {
+	const lockQuery string = `SELECT 1 FROM users_rooms WHERE user_id = $1 AND room_id = $2 FOR UPDATE`
+	const lastSentQuery string = `
+	SELECT created_at FROM messages
+	WHERE room_id = $1 AND author = $2 AND kind = 'user'
+	ORDER BY created_at DESC
+	LIMIT 1
+	`
+	const query string = `INSERT INTO messages (id, room_id, author, content, kind, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	if slowMode <= 0 {
+		return nil, p.CreateMessage(ctx, message)
+	}
+
+	return utils.Retry(ctx, func(ctx context.Context) (*time.Time, error) {
+		tx, err := p.Pool.Begin(ctx)
 		if err != nil {
-			return struct{}{}, err
+			return nil, err
+		}
+		defer func() { _ = tx.Rollback(ctx) }()
+
+		var exists int
+		if err := tx.QueryRow(ctx, lockQuery, message.Author, message.RoomId).Scan(&exists); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil, utils.CreateNonRetryableError(xerrors.NotFoundError("room", map[string]string{
+					"id": message.RoomId.String(),
+				}))
+			}
+
+			return nil, err
 		}
 
-		if ct.RowsAffected() == 0 {
-			return struct{}{}, utils.CreateNonRetryableError(
-				xerrors.NotFoundError("message", map[string]string{
-					"id":     messageId.String(),
-					"author": userId.String(),
-				}),
-			)
+		var lastSent time.Time
+		if err := tx.QueryRow(ctx, lastSentQuery, message.RoomId, message.Author).Scan(&lastSent); err != nil {
+			if !errors.Is(err, pgx.ErrNoRows) {
+				return nil, err
+			}
+		} else if retryAfter := lastSent.Add(slowMode); message.CreatedAt.Before(retryAfter) {
+			return &retryAfter, nil
 		}
 
-		return struct{}{}, nil
+		if _, err := tx.Exec(ctx, query,
+			message.Id,
+			message.RoomId,
+			message.Author,
+			message.Content,
+			message.Kind,
+			message.CreatedAt,
+			message.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		return nil, tx.Commit(ctx)
 	})
+}
 
-	return err
+func (p *Postgres) GetMessagesByAuthor(ctx context.Context, userId uuid.UUID) ([]models.Message, error) {
+	const query string = `
+	SELECT id, room_id, author, content, kind, created_at, updated_at
+	FROM messages
+	WHERE author = $1
+	ORDER BY created_at ASC
+	`
+
+	rows, err := utils.Retry(ctx, func(ctx context.Context) (pgx.Rows, error) {
+		return p.Pool.Query(ctx, query, userId)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.Message, error) {
+		message, err := pgx.RowToStructByName[models.Message](row)
+		if err != nil {
+			return models.Message{}, err
+		}
+
+		return message, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
 }
@@ -0,0 +1,78 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"go-chat/internal/models"
+	"go-chat/internal/types"
+	"go-chat/internal/utils"
+	"go-chat/internal/xerrors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+func (p *Postgres) GetPreferences(ctx context.Context, userId uuid.UUID) (models.Preferences, error) {
+	const query string = `SELECT preferences, preferences_version FROM profiles WHERE user_id = $1`
+
+	rows, err := utils.Retry(ctx, func(ctx context.Context) (pgx.Rows, error) {
+		return p.Pool.Query(ctx, query, userId)
+	})
+	if err != nil {
+		return models.Preferences{}, err
+	}
+
+	preferences, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.Preferences])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Preferences{}, xerrors.NotFoundError("profile", map[string]string{
+				"user_id": userId.String(),
+			})
+		}
+
+		return models.Preferences{}, err
+	}
+
+	return preferences, nil
+}
+
+// PatchPreferences merges the patch into the user's preferences. When expectedVersion is set the patch only applies
+// if the stored preferences are still at that version.
+func (p *Postgres) PatchPreferences(ctx context.Context, patch types.PreferencesPatch, expectedVersion *int64, userId uuid.UUID) (models.Preferences, error) {
+	const query string = `
+	UPDATE profiles
+	SET preferences = (preferences || $2::jsonb) - $3::text[],
+	    preferences_version = preferences_version + 1
+	WHERE user_id = $1 AND ($4::bigint IS NULL OR preferences_version = $4)
+	RETURNING preferences, preferences_version
+	`
+
+	values, err := patch.Values()
+	if err != nil {
+		return models.Preferences{}, err
+	}
+
+	return utils.Retry(ctx, func(ctx context.Context) (models.Preferences, error) {
+		rows, err := p.Pool.Query(ctx, query, userId, values, patch.Removals(), expectedVersion)
+		if err != nil {
+			return models.Preferences{}, err
+		}
+
+		preferences, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.Preferences])
+		if err != nil {
+			if !errors.Is(err, pgx.ErrNoRows) {
+				return models.Preferences{}, err
+			}
+
+			// the profile is missing or its preferences moved past the expected version
+			if _, err := p.GetPreferences(ctx, userId); err != nil {
+				return models.Preferences{}, utils.CreateNonRetryableError(err)
+			}
+
+			return models.Preferences{}, utils.CreateNonRetryableError(xerrors.PreconditionFailedError("preferences have changed since they were read"))
+		}
+
+		return preferences, nil
+	})
+}
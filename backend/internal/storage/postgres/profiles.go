@@ -3,12 +3,16 @@ package postgres
 import (
 	"context"
 	"errors"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Masterminds/squirrel"
 
 	"go-chat/internal/constants"
 	"go-chat/internal/models"
 	"go-chat/internal/types"
+	"go-chat/internal/usernames"
 	"go-chat/internal/utils"
 	"go-chat/internal/xerrors"
 
@@ -16,8 +20,16 @@ import (
 	"github.com/jackc/pgx/v5"
 )
 
+const (
+	profileColumns         = "user_id, username, first_name, last_name, avatar_url, last_seen_at, created_at, updated_at"
+	prefixedProfileColumns = "p.user_id, p.username, p.first_name, p.last_name, p.avatar_url, p.last_seen_at, p.created_at, p.updated_at"
+	// profileFullNameExpr must match the expression of the profiles_full_name_trgm_idx index
+	profileFullNameExpr  = "(first_name || ' ' || last_name)"
+	profileDetailColumns = "bio, pronouns, timezone, locale, links"
+)
+
 func (p *Postgres) GetProfileByUserId(ctx context.Context, userId uuid.UUID) (models.Profile, error) {
-	const query string = `SELECT user_id, username, first_name, last_name, created_at, updated_at FROM profiles WHERE user_id = $1`
+	const query string = `SELECT ` + profileColumns + ` FROM profiles WHERE user_id = $1`
 
 	rows, err := utils.Retry(ctx, func(ctx context.Context) (pgx.Rows, error) {
 		return p.Pool.Query(ctx, query, userId)
@@ -40,6 +52,32 @@ func (p *Postgres) GetProfileByUserId(ctx context.Context, userId uuid.UUID) (mo
 	return profile, nil
 }
 
+// GetDetailedProfileByUserId returns the profile together with its details, which are left out of the compact
+// profiles returned elsewhere
+func (p *Postgres) GetDetailedProfileByUserId(ctx context.Context, userId uuid.UUID) (types.DetailedProfile, error) {
+	const query string = `SELECT ` + profileColumns + `, ` + profileDetailColumns + ` FROM profiles WHERE user_id = $1`
+
+	rows, err := utils.Retry(ctx, func(ctx context.Context) (pgx.Rows, error) {
+		return p.Pool.Query(ctx, query, userId)
+	})
+	if err != nil {
+		return types.DetailedProfile{}, err
+	}
+
+	profile, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[types.DetailedProfile])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return types.DetailedProfile{}, xerrors.NotFoundError("profile", map[string]string{
+				"user_id": userId.String(),
+			})
+		}
+
+		return types.DetailedProfile{}, err
+	}
+
+	return profile, nil
+}
+
 func (p *Postgres) PatchProfileByUserId(ctx context.Context, partialProfile types.PartialProfile, userId uuid.UUID) error {
 	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
 
@@ -54,7 +92,29 @@ func (p *Postgres) PatchProfileByUserId(ctx context.Context, partialProfile type
 	}
 
 	if partialProfile.Username != nil {
-		builder = builder.Set("username", *partialProfile.Username)
+		builder = builder.
+			Set("username", *partialProfile.Username).
+			Set("username_skeleton", usernames.Skeleton(*partialProfile.Username))
+	}
+
+	if partialProfile.Bio != nil {
+		builder = builder.Set("bio", *partialProfile.Bio)
+	}
+
+	if partialProfile.Pronouns != nil {
+		builder = builder.Set("pronouns", *partialProfile.Pronouns)
+	}
+
+	if partialProfile.Timezone != nil {
+		builder = builder.Set("timezone", *partialProfile.Timezone)
+	}
+
+	if partialProfile.Locale != nil {
+		builder = builder.Set("locale", *partialProfile.Locale)
+	}
+
+	if partialProfile.Links != nil {
+		builder = builder.Set("links", *partialProfile.Links)
 	}
 
 	builder = builder.Set("updated_at", partialProfile.UpdatedAt)
@@ -68,10 +128,15 @@ func (p *Postgres) PatchProfileByUserId(ctx context.Context, partialProfile type
 	_, err = utils.Retry(ctx, func(ctx context.Context) (struct{}, error) {
 		ct, err := p.Pool.Exec(ctx, query, args...)
 		if err != nil {
-			if xerrors.IsUniqueViolation(err, constants.ProfilesUsernameUniqueConstraint) {
+			if xerrors.IsUniqueViolation(err, constants.ProfilesUsernameUniqueConstraint) ||
+				xerrors.IsUniqueViolation(err, constants.ProfilesUsernameLowerUniqueConstraint) {
 				return struct{}{}, utils.CreateNonRetryableError(xerrors.ConflictError("user", "username", *partialProfile.Username))
 			}
 
+			if xerrors.IsUniqueViolation(err, constants.ProfilesUsernameSkeletonUniqueConstraint) {
+				return struct{}{}, utils.CreateNonRetryableError(xerrors.ConfusableUsernameError())
+			}
+
 			return struct{}{}, err
 		}
 
@@ -87,36 +152,158 @@ func (p *Postgres) PatchProfileByUserId(ctx context.Context, partialProfile type
 	return err
 }
 
-func (p *Postgres) CreateProfile(ctx context.Context, profile models.Profile) error {
-	const query string = `INSERT INTO profiles (user_id, username, first_name, last_name, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`
+// CreateProfile creates the profile together with the user's personal workspace, which the user owns, in a single
+// transaction so a user never ends up with a profile but no workspace
+func (p *Postgres) CreateProfile(ctx context.Context, profile models.Profile, workspace models.Workspace) error {
+	const query string = `
+	INSERT INTO profiles (user_id, username, username_skeleton, first_name, last_name, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	const workspacesQuery string = `INSERT INTO workspaces (id, name, dms_contacts_only, created_at, updated_at) VALUES ($1, $2, $3, $4, $5)`
+	const membersQuery string = `INSERT INTO workspace_members (workspace_id, user_id, role) VALUES ($1, $2, 'owner')`
 
 	_, err := utils.Retry(ctx, func(ctx context.Context) (struct{}, error) {
-		_, err := p.Pool.Exec(ctx, query, profile.UserId, profile.Username, profile.FirstName, profile.LastName, profile.CreatedAt, profile.UpdatedAt)
+		tx, err := p.Pool.Begin(ctx)
+		if err != nil {
+			return struct{}{}, err
+		}
+		defer func() { _ = tx.Rollback(ctx) }()
+
+		_, err = tx.Exec(ctx, query, profile.UserId, profile.Username, usernames.Skeleton(profile.Username), profile.FirstName, profile.LastName, profile.CreatedAt, profile.UpdatedAt)
 		if err != nil {
 			if xerrors.IsUniqueViolation(err, constants.ProfilesPKeyUniqueConstraint) {
 				return struct{}{}, utils.CreateNonRetryableError(xerrors.ConflictError("profile", "id", profile.UserId.String()))
-			} else if xerrors.IsUniqueViolation(err, constants.ProfilesUsernameUniqueConstraint) {
+			} else if xerrors.IsUniqueViolation(err, constants.ProfilesUsernameUniqueConstraint) ||
+				xerrors.IsUniqueViolation(err, constants.ProfilesUsernameLowerUniqueConstraint) {
 				return struct{}{}, utils.CreateNonRetryableError(xerrors.ConflictError("user", "username", profile.Username))
+			} else if xerrors.IsUniqueViolation(err, constants.ProfilesUsernameSkeletonUniqueConstraint) {
+				return struct{}{}, utils.CreateNonRetryableError(xerrors.ConfusableUsernameError())
 			}
+
+			return struct{}{}, err
 		}
 
-		return struct{}{}, err
+		if _, err := tx.Exec(ctx, workspacesQuery, workspace.Id, workspace.Name, workspace.DmsContactsOnly, workspace.CreatedAt, workspace.UpdatedAt); err != nil {
+			return struct{}{}, err
+		}
+
+		if _, err := tx.Exec(ctx, membersQuery, workspace.Id, profile.UserId); err != nil {
+			return struct{}{}, err
+		}
+
+		return struct{}{}, tx.Commit(ctx)
 	})
 
 	return err
 }
 
-func (p *Postgres) SearchProfiles(ctx context.Context, options types.SearchProfilesOptions, userId uuid.UUID) ([]models.Profile, error) {
+// SearchProfiles finds profiles in the workspace whose selected fields contain or are trigram similar to the query.
+// Contacts always come first. Within them and within everyone else, sorting by relevance ranks exact matches, then
+// prefix matches, then the closest trigram matches.
+// The total is counted over every match, so a page past the last match still reports it.
+func (p *Postgres) SearchProfiles(ctx context.Context, options types.SearchProfilesOptions, userId uuid.UUID, workspaceId uuid.UUID) (types.SearchProfilesResult, error) {
+	page, count := searchProfilesQueries(options, userId, workspaceId)
+
+	query, args, err := page.ToSql()
+	if err != nil {
+		return types.SearchProfilesResult{}, err
+	}
+
+	countQuery, countArgs, err := count.ToSql()
+	if err != nil {
+		return types.SearchProfilesResult{}, err
+	}
+
+	return utils.Retry(ctx, func(ctx context.Context) (types.SearchProfilesResult, error) {
+		// both queries read the same snapshot so the total agrees with the page
+		tx, err := p.Pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+		if err != nil {
+			return types.SearchProfilesResult{}, err
+		}
+		defer func() { _ = tx.Rollback(ctx) }()
+
+		rows, err := tx.Query(ctx, query, args...)
+		if err != nil {
+			return types.SearchProfilesResult{}, err
+		}
+
+		profiles, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.Profile])
+		if err != nil {
+			return types.SearchProfilesResult{}, err
+		}
+
+		var total int
+		if err := tx.QueryRow(ctx, countQuery, countArgs...).Scan(&total); err != nil {
+			return types.SearchProfilesResult{}, err
+		}
+
+		return types.SearchProfilesResult{
+			Profiles: profiles,
+			Total:    total,
+		}, tx.Commit(ctx)
+	})
+}
+
+// searchProfilesQueries builds the query for the requested page of matches and the query counting every match
+func searchProfilesQueries(options types.SearchProfilesOptions, userId uuid.UUID, workspaceId uuid.UUID) (squirrel.SelectBuilder, squirrel.SelectBuilder) {
 	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
 
-	builder := psql.
-		Select("user_id, username, first_name, last_name, created_at, updated_at").
+	var searchExprs []string
+	if options.HasField(types.ProfileSearchFieldUsername) {
+		searchExprs = append(searchExprs, "username")
+	}
+	if options.HasField(types.ProfileSearchFieldFirstName) {
+		searchExprs = append(searchExprs, "first_name")
+	}
+	if options.HasField(types.ProfileSearchFieldLastName) {
+		searchExprs = append(searchExprs, "last_name")
+	}
+	if options.HasField(types.ProfileSearchFieldFirstName) && options.HasField(types.ProfileSearchFieldLastName) {
+		searchExprs = append(searchExprs, profileFullNameExpr)
+	}
+
+	var matches squirrel.Or
+	var exactClauses, prefixClauses, similarityClauses []string
+	var exactArgs, prefixArgs, similarityArgs []any
+	for _, expr := range searchExprs {
+		matches = append(matches, squirrel.Expr(expr+" ILIKE ? OR ? <% "+expr, "%"+options.Query+"%", options.Query))
+		exactClauses = append(exactClauses, expr+" ILIKE ?")
+		exactArgs = append(exactArgs, options.Query)
+		prefixClauses = append(prefixClauses, expr+" ILIKE ?")
+		prefixArgs = append(prefixArgs, options.Query+"%")
+		similarityClauses = append(similarityClauses, "word_similarity(?, "+expr+")")
+		similarityArgs = append(similarityArgs, options.Query)
+	}
+
+	filtered := psql.
+		Select().
 		From("profiles").
-		Where("username ILIKE ?", "%"+options.Username+"%").
-		Where("user_id != ?", userId.String())
+		Where(matches).
+		Where("user_id != ?", userId.String()).
+		Where(
+			squirrel.Expr(
+				`EXISTS (
+					SELECT 1 FROM workspace_members
+					WHERE workspace_members.user_id = profiles.user_id
+						AND workspace_members.workspace_id = ?
+				)`,
+				workspaceId.String(),
+			),
+		).
+		Where(
+			squirrel.Expr(
+				`NOT EXISTS (
+					SELECT 1 FROM user_blocks
+					WHERE (user_blocks.blocker = ? AND user_blocks.blocked = profiles.user_id)
+						OR (user_blocks.blocker = profiles.user_id AND user_blocks.blocked = ?)
+				)`,
+				userId.String(),
+				userId.String(),
+			),
+		)
 
 	if options.ExcludeRoom != nil {
-		builder = builder.
+		filtered = filtered.
 			Where(
 				squirrel.Expr(
 					`NOT EXISTS (
@@ -129,33 +316,139 @@ func (p *Postgres) SearchProfiles(ctx context.Context, options types.SearchProfi
 			)
 	}
 
+	builder := filtered.
+		Columns(profileColumns).
+		OrderByClause(
+			`EXISTS (
+				SELECT 1 FROM contacts
+				WHERE contacts.status = 'accepted'
+					AND ((contacts.requester = ? AND contacts.recipient = profiles.user_id)
+						OR (contacts.requester = profiles.user_id AND contacts.recipient = ?))
+			) DESC`,
+			userId.String(),
+			userId.String(),
+		)
+
+	switch options.Sort {
+	case types.ProfileSearchSortUsername:
+		builder = builder.OrderBy("username " + options.Order)
+	case types.ProfileSearchSortCreatedAt:
+		builder = builder.OrderBy("created_at "+options.Order, "username ASC")
+	default:
+		builder = builder.
+			OrderByClause(
+				"CASE WHEN "+strings.Join(exactClauses, " OR ")+" THEN 0 WHEN "+strings.Join(prefixClauses, " OR ")+" THEN 1 ELSE 2 END",
+				append(exactArgs, prefixArgs...)...,
+			).
+			OrderByClause("GREATEST("+strings.Join(similarityClauses, ", ")+") DESC", similarityArgs...).
+			OrderBy("username ASC")
+	}
+
 	builder = builder.
 		Limit(uint64(options.Limit)).
 		Offset(uint64(options.Offset))
 
-	query, args, err := builder.ToSql()
-	if err != nil {
-		return nil, err
-	}
+	return builder, filtered.Columns("COUNT(*)")
+}
+
+// SetProfileAvatar replaces the user's stored avatar images, keyed by size, and points their profile at the new url
+func (p *Postgres) SetProfileAvatar(ctx context.Context, userId uuid.UUID, images map[int][]byte, avatarUrl string, updatedAt time.Time) error {
+	const deleteQuery string = `DELETE FROM profile_avatars WHERE user_id = $1`
+	const insertQuery string = `INSERT INTO profile_avatars (user_id, size, data, updated_at) VALUES ($1, $2, $3, $4)`
+	const profileQuery string = `UPDATE profiles SET avatar_url = $2, updated_at = $3 WHERE user_id = $1`
+
+	_, err := utils.Retry(ctx, func(ctx context.Context) (struct{}, error) {
+		tx, err := p.Pool.Begin(ctx)
+		if err != nil {
+			return struct{}{}, err
+		}
+		defer func() { _ = tx.Rollback(ctx) }()
+
+		ct, err := tx.Exec(ctx, profileQuery, userId, avatarUrl, updatedAt)
+		if err != nil {
+			return struct{}{}, err
+		}
+
+		if ct.RowsAffected() == 0 {
+			return struct{}{}, utils.CreateNonRetryableError(xerrors.NotFoundError("profile", map[string]string{
+				"user_id": userId.String(),
+			}))
+		}
+
+		if _, err := tx.Exec(ctx, deleteQuery, userId); err != nil {
+			return struct{}{}, err
+		}
+
+		batch := &pgx.Batch{}
+		for size, data := range images {
+			batch.Queue(insertQuery, userId, size, data, updatedAt)
+		}
+
+		if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+			return struct{}{}, err
+		}
+
+		return struct{}{}, tx.Commit(ctx)
+	})
+
+	return err
+}
+
+func (p *Postgres) GetProfileAvatar(ctx context.Context, userId uuid.UUID, size int) (models.Avatar, error) {
+	const query string = `SELECT user_id, size, data, updated_at FROM profile_avatars WHERE user_id = $1 AND size = $2`
 
 	rows, err := utils.Retry(ctx, func(ctx context.Context) (pgx.Rows, error) {
-		return p.Pool.Query(ctx, query, args...)
+		return p.Pool.Query(ctx, query, userId, size)
 	})
 	if err != nil {
-		return nil, err
+		return models.Avatar{}, err
+	}
+
+	avatar, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.Avatar])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Avatar{}, xerrors.NotFoundError("avatar", map[string]string{
+				"user_id": userId.String(),
+				"size":    strconv.Itoa(size),
+			})
+		}
+
+		return models.Avatar{}, err
 	}
 
-	profiles, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.Profile, error) {
-		profile, err := pgx.RowToStructByName[models.Profile](row)
+	return avatar, nil
+}
+
+func (p *Postgres) SetProfileLastSeen(ctx context.Context, userId uuid.UUID, lastSeenAt time.Time) error {
+	const query string = `UPDATE profiles SET last_seen_at = $2 WHERE user_id = $1`
+
+	_, err := utils.Retry(ctx, func(ctx context.Context) (struct{}, error) {
+		_, err := p.Pool.Exec(ctx, query, userId, lastSeenAt)
+		return struct{}{}, err
+	})
+
+	return err
+}
+
+// DeleteAccount removes the user's auth account, cascading to their profile and everything keyed on it. Triggers on
+// profiles hand over their hosted rooms and owned workspaces, and their messages remain with the author cleared.
+func (p *Postgres) DeleteAccount(ctx context.Context, userId uuid.UUID) error {
+	const query string = `DELETE FROM auth.users WHERE id = $1`
+
+	_, err := utils.Retry(ctx, func(ctx context.Context) (struct{}, error) {
+		ct, err := p.Pool.Exec(ctx, query, userId)
 		if err != nil {
-			return models.Profile{}, err
+			return struct{}{}, err
 		}
 
-		return profile, nil
+		if ct.RowsAffected() == 0 {
+			return struct{}{}, utils.CreateNonRetryableError(xerrors.NotFoundError("user", map[string]string{
+				"id": userId.String(),
+			}))
+		}
+
+		return struct{}{}, nil
 	})
-	if err != nil {
-		return nil, err
-	}
 
-	return profiles, nil
+	return err
 }
@@ -0,0 +1,30 @@
+package postgres
+
+import (
+	"testing"
+
+	"go-chat/internal/types"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchProfilesQueries_OutOfRangePage(t *testing.T) {
+	options := types.SearchProfilesOptions{Query: "aaron", Limit: 10, Offset: 1000}
+	require.Empty(t, options.Validate())
+
+	page, count := searchProfilesQueries(options, uuid.New(), uuid.New())
+
+	pageQuery, _, err := page.ToSql()
+	require.NoError(t, err)
+	assert.Contains(t, pageQuery, "LIMIT 10 OFFSET 1000")
+
+	countQuery, countArgs, err := count.ToSql()
+	require.NoError(t, err)
+	assert.Contains(t, countQuery, "SELECT COUNT(*) FROM profiles")
+	assert.NotContains(t, countQuery, "LIMIT", "the total counts every match regardless of the page")
+	assert.NotContains(t, countQuery, "OFFSET", "the total counts every match regardless of the page")
+	assert.NotContains(t, countQuery, "ORDER BY")
+	assert.NotEmpty(t, countArgs)
+}
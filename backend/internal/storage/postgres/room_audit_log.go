@@ -0,0 +1,95 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"go-chat/internal/models"
+	"go-chat/internal/types"
+	"go-chat/internal/utils"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+func (p *Postgres) CreateAuditEntry(ctx context.Context, entry models.AuditEntry) error {
+	const query string = `
+	INSERT INTO room_audit_log (id, room_id, actor, action, target, details, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	_, err := utils.Retry(ctx, func(ctx context.Context) (struct{}, error) {
+		_, err := p.Pool.Exec(ctx, query,
+			entry.Id,
+			entry.RoomId,
+			entry.Actor,
+			entry.Action,
+			entry.Target,
+			entry.Details,
+			entry.CreatedAt,
+		)
+
+		return struct{}{}, err
+	})
+
+	return err
+}
+
+func (p *Postgres) GetAuditLogByRoomId(ctx context.Context, options types.AuditLogOptions, roomId uuid.UUID) ([]models.AuditEntry, error) {
+	const query string = `
+	SELECT id, room_id, actor, action, target, details, created_at
+	FROM room_audit_log
+	WHERE room_id = $1
+	ORDER BY created_at DESC, id ASC
+	LIMIT $2 OFFSET $3
+	`
+
+	rows, err := utils.Retry(ctx, func(ctx context.Context) (pgx.Rows, error) {
+		return p.Pool.Query(ctx, query, roomId, options.Limit, options.Offset)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.AuditEntry, error) {
+		entry, err := pgx.RowToStructByName[models.AuditEntry](row)
+		if err != nil {
+			return models.AuditEntry{}, err
+		}
+
+		return entry, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// CheckUserCanReadAuditLog reports whether the user may read the room's audit log, either as the host or an admin of
+// the room or, once the room is deleted, as someone who was one when it was deleted
+func (p *Postgres) CheckUserCanReadAuditLog(ctx context.Context, roomId uuid.UUID, userId uuid.UUID) (bool, error) {
+	const query string = `
+	SELECT 1
+	FROM users_rooms AS ur
+	INNER JOIN rooms AS r ON ur.room_id = r.id
+	WHERE ur.user_id = $1 AND ur.room_id = $2
+	  AND (r.host = $1 OR ur.role = 'admin')
+	UNION ALL
+	SELECT 1 FROM room_audit_readers WHERE user_id = $1 AND room_id = $2
+	LIMIT 1
+	`
+
+	var exists int
+	return utils.Retry(ctx, func(ctx context.Context) (bool, error) {
+		if err := p.Pool.QueryRow(ctx, query, userId, roomId).Scan(&exists); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return false, nil
+			}
+
+			return false, err
+		}
+
+		return true, nil
+	})
+}
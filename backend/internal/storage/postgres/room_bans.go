@@ -0,0 +1,132 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"go-chat/internal/models"
+	"go-chat/internal/utils"
+	"go-chat/internal/xerrors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// BanUserFromRoom records the ban and removes the user from the room along with any mute and pending invitation
+// they have there. The host cannot be banned.
+func (p *Postgres) BanUserFromRoom(ctx context.Context, ban models.RoomBan) error {
+	const banQuery string = `
+	INSERT INTO room_bans (room_id, user_id, banned_by, reason, created_at)
+	SELECT id, $2, $3, $4, $5 FROM rooms
+	WHERE id = $1 AND host IS DISTINCT FROM $2
+	ON CONFLICT DO NOTHING
+	`
+	const usersRoomsQuery string = `DELETE FROM users_rooms WHERE room_id = $1 AND user_id = $2`
+	const mutesQuery string = `DELETE FROM room_mutes WHERE room_id = $1 AND user_id = $2`
+	const invitationsQuery string = `
+	UPDATE room_invitations SET status = 'declined', updated_at = $3
+	WHERE room_id = $1 AND invitee = $2 AND status = 'pending'
+	`
+
+	_, err := utils.Retry(ctx, func(ctx context.Context) (struct{}, error) {
+		tx, err := p.Pool.Begin(ctx)
+		if err != nil {
+			return struct{}{}, err
+		}
+		defer func() { _ = tx.Rollback(ctx) }()
+
+		ct, err := tx.Exec(ctx, banQuery, ban.RoomId, ban.UserId, ban.BannedBy, ban.Reason, ban.CreatedAt)
+		if err != nil {
+			return struct{}{}, err
+		}
+
+		if ct.RowsAffected() == 0 {
+			return struct{}{}, utils.CreateNonRetryableError(xerrors.ConflictError("ban", "user_id", ban.UserId.String()))
+		}
+
+		if _, err := tx.Exec(ctx, usersRoomsQuery, ban.RoomId, ban.UserId); err != nil {
+			return struct{}{}, err
+		}
+
+		if _, err := tx.Exec(ctx, mutesQuery, ban.RoomId, ban.UserId); err != nil {
+			return struct{}{}, err
+		}
+
+		if _, err := tx.Exec(ctx, invitationsQuery, ban.RoomId, ban.UserId, ban.CreatedAt); err != nil {
+			return struct{}{}, err
+		}
+
+		return struct{}{}, tx.Commit(ctx)
+	})
+
+	return err
+}
+
+func (p *Postgres) UnbanUserFromRoom(ctx context.Context, roomId uuid.UUID, userId uuid.UUID) error {
+	const query string = `DELETE FROM room_bans WHERE room_id = $1 AND user_id = $2`
+
+	_, err := utils.Retry(ctx, func(ctx context.Context) (struct{}, error) {
+		ct, err := p.Pool.Exec(ctx, query, roomId, userId)
+		if err != nil {
+			return struct{}{}, err
+		}
+
+		if ct.RowsAffected() == 0 {
+			return struct{}{}, utils.CreateNonRetryableError(xerrors.NotFoundError("ban", map[string]string{
+				"room_id": roomId.String(),
+				"user_id": userId.String(),
+			}))
+		}
+
+		return struct{}{}, nil
+	})
+
+	return err
+}
+
+func (p *Postgres) GetBansByRoomId(ctx context.Context, roomId uuid.UUID) ([]models.RoomBan, error) {
+	const query string = `
+	SELECT room_id, user_id, banned_by, reason, created_at
+	FROM room_bans
+	WHERE room_id = $1
+	ORDER BY created_at DESC
+	`
+
+	rows, err := utils.Retry(ctx, func(ctx context.Context) (pgx.Rows, error) {
+		return p.Pool.Query(ctx, query, roomId)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	bans, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.RoomBan, error) {
+		ban, err := pgx.RowToStructByName[models.RoomBan](row)
+		if err != nil {
+			return models.RoomBan{}, err
+		}
+
+		return ban, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return bans, nil
+}
+
+func (p *Postgres) CheckUserBannedFromRoom(ctx context.Context, roomId uuid.UUID, userId uuid.UUID) (bool, error) {
+	const query string = `SELECT 1 FROM room_bans WHERE room_id = $1 AND user_id = $2`
+
+	var exists int
+	return utils.Retry(ctx, func(ctx context.Context) (bool, error) {
+		if err := p.Pool.QueryRow(ctx, query, roomId, userId).Scan(&exists); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return false, nil
+			}
+
+			return false, err
+		}
+
+		return true, nil
+	})
+}
@@ -0,0 +1,116 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go-chat/internal/models"
+	"go-chat/internal/utils"
+	"go-chat/internal/xerrors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// MuteUserInRoom mutes a member of the room, replacing any existing mute. The host cannot be muted.
+func (p *Postgres) MuteUserInRoom(ctx context.Context, mute models.RoomMute) error {
+	const query string = `
+	INSERT INTO room_mutes (room_id, user_id, muted_by, expires_at, created_at)
+	SELECT ur.room_id, ur.user_id, $3, $4, $5
+	FROM users_rooms AS ur
+	INNER JOIN rooms AS r ON ur.room_id = r.id
+	WHERE ur.room_id = $1 AND ur.user_id = $2 AND r.host IS DISTINCT FROM $2
+	ON CONFLICT (room_id, user_id) DO UPDATE
+	SET muted_by = EXCLUDED.muted_by, expires_at = EXCLUDED.expires_at, created_at = EXCLUDED.created_at
+	`
+
+	_, err := utils.Retry(ctx, func(ctx context.Context) (struct{}, error) {
+		ct, err := p.Pool.Exec(ctx, query, mute.RoomId, mute.UserId, mute.MutedBy, mute.ExpiresAt, mute.CreatedAt)
+		if err != nil {
+			return struct{}{}, err
+		}
+
+		if ct.RowsAffected() == 0 {
+			return struct{}{}, utils.CreateNonRetryableError(xerrors.NotFoundError("room member", map[string]string{
+				"room_id": mute.RoomId.String(),
+				"user_id": mute.UserId.String(),
+			}))
+		}
+
+		return struct{}{}, nil
+	})
+
+	return err
+}
+
+func (p *Postgres) UnmuteUserInRoom(ctx context.Context, roomId uuid.UUID, userId uuid.UUID) error {
+	const query string = `DELETE FROM room_mutes WHERE room_id = $1 AND user_id = $2 AND expires_at > now()`
+
+	_, err := utils.Retry(ctx, func(ctx context.Context) (struct{}, error) {
+		ct, err := p.Pool.Exec(ctx, query, roomId, userId)
+		if err != nil {
+			return struct{}{}, err
+		}
+
+		if ct.RowsAffected() == 0 {
+			return struct{}{}, utils.CreateNonRetryableError(xerrors.NotFoundError("mute", map[string]string{
+				"room_id": roomId.String(),
+				"user_id": userId.String(),
+			}))
+		}
+
+		return struct{}{}, nil
+	})
+
+	return err
+}
+
+// GetMutesByRoomId returns the mutes in the room that have not yet expired
+func (p *Postgres) GetMutesByRoomId(ctx context.Context, roomId uuid.UUID) ([]models.RoomMute, error) {
+	const query string = `
+	SELECT room_id, user_id, muted_by, expires_at, created_at
+	FROM room_mutes
+	WHERE room_id = $1 AND expires_at > now()
+	ORDER BY expires_at ASC
+	`
+
+	rows, err := utils.Retry(ctx, func(ctx context.Context) (pgx.Rows, error) {
+		return p.Pool.Query(ctx, query, roomId)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	mutes, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.RoomMute, error) {
+		mute, err := pgx.RowToStructByName[models.RoomMute](row)
+		if err != nil {
+			return models.RoomMute{}, err
+		}
+
+		return mute, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return mutes, nil
+}
+
+// GetRoomMuteExpiry returns when the user's mute in the room ends, or nil if they are not currently muted
+func (p *Postgres) GetRoomMuteExpiry(ctx context.Context, roomId uuid.UUID, userId uuid.UUID) (*time.Time, error) {
+	const query string = `SELECT expires_at FROM room_mutes WHERE room_id = $1 AND user_id = $2 AND expires_at > now()`
+
+	return utils.Retry(ctx, func(ctx context.Context) (*time.Time, error) {
+		var expiresAt time.Time
+		if err := p.Pool.QueryRow(ctx, query, roomId, userId).Scan(&expiresAt); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil, nil
+			}
+
+			return nil, err
+		}
+
+		return &expiresAt, nil
+	})
+}
@@ -2,82 +2,118 @@ package postgres
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"go-chat/internal/models"
 	"go-chat/internal/types"
 	"go-chat/internal/utils"
 	"go-chat/internal/xerrors"
 
+	"github.com/Masterminds/squirrel"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgconn"
 )
 
-func (p *Postgres) CreateRoom(ctx context.Context, room models.Room, members []uuid.UUID) (types.BulkResult[uuid.UUID], error) {
-	const roomsQuery string = `INSERT INTO rooms (id, host, name, created_at, updated_at) VALUES ($1, $2, $3, $4, $5)`
-	const usersRoomsHostQuery = `INSERT INTO users_rooms (user_id, room_id) VALUES ($1, $2)`
-	const usersRoomsMemberQuery string = `
-	INSERT INTO users_rooms (user_id, room_id)
-	SELECT $1, $2
-	WHERE EXISTS (
-		SELECT 1 FROM profiles WHERE user_id = $1
-	)
+const (
+	roomColumns         string = "id, host, name, kind, visibility, topic, description, avatar, archived_at, posting_policy, slow_mode_seconds, workspace_id, created_at, updated_at"
+	prefixedRoomColumns string = "r.id, r.host, r.name, r.kind, r.visibility, r.topic, r.description, r.avatar, r.archived_at, r.posting_policy, r.slow_mode_seconds, r.workspace_id, r.created_at, r.updated_at"
+)
+
+func (p *Postgres) CreateRoom(ctx context.Context, room models.Room) error {
+	const roomsQuery string = `
+	INSERT INTO rooms (id, host, name, kind, visibility, posting_policy, workspace_id, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 	`
+	const usersRoomsHostQuery = `INSERT INTO users_rooms (user_id, room_id) VALUES ($1, $2)`
 
-	bulkResult := types.BulkResult[uuid.UUID]{}
 	batch := &pgx.Batch{}
-	batch.Queue(roomsQuery, room.Id, room.Host, room.Name, room.CreatedAt, room.UpdatedAt)
+	batch.Queue(roomsQuery, room.Id, room.Host, room.Name, room.Kind, room.Visibility, room.PostingPolicy, room.WorkspaceId, room.CreatedAt, room.UpdatedAt)
 	batch.Queue(usersRoomsHostQuery, room.Host, room.Id)
-	for _, userId := range members {
-		batch.Queue(usersRoomsMemberQuery, userId, room.Id).Exec(func(ct pgconn.CommandTag) error {
-			if ct.RowsAffected() == 0 {
-				bulkResult.Failures = append(bulkResult.Failures, types.Failure[uuid.UUID]{
-					Item:    userId,
-					Message: "failed to add user to room",
-				})
-			} else {
-				bulkResult.Successes = append(bulkResult.Successes, userId)
-			}
 
-			return nil
-		})
+	results := p.Pool.SendBatch(ctx, batch)
+	return results.Close()
+}
+
+func (p *Postgres) GetRoomById(ctx context.Context, roomId uuid.UUID) (models.Room, error) {
+	const query string = `SELECT ` + roomColumns + ` FROM rooms WHERE id = $1`
+
+	rows, err := utils.Retry(ctx, func(ctx context.Context) (pgx.Rows, error) {
+		return p.Pool.Query(ctx, query, roomId)
+	})
+	if err != nil {
+		return models.Room{}, err
 	}
 
-	results := p.Pool.SendBatch(ctx, batch)
-	if err := results.Close(); err != nil {
-		return types.BulkResult[uuid.UUID]{}, err
+	room, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.Room])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Room{}, xerrors.NotFoundError("room", map[string]string{
+				"id": roomId.String(),
+			})
+		}
+
+		return models.Room{}, err
 	}
 
-	return bulkResult, nil
+	return room, nil
 }
 
-func (p *Postgres) GetRoomsByUserId(ctx context.Context, userId uuid.UUID) ([]models.Room, error) {
+func (p *Postgres) GetRoomsByUserId(ctx context.Context, options types.GetRoomsOptions, userId uuid.UUID, workspaceId uuid.UUID) ([]types.UserRoom, error) {
 	const query string = `
 	SELECT
 	    r.id,
 	    r.host,
 	    r.name,
+	    r.kind,
+	    r.visibility,
+	    r.topic,
+	    r.description,
+	    r.avatar,
+	    r.archived_at,
+	    r.posting_policy,
+	    r.slow_mode_seconds,
+	    r.workspace_id,
 	    r.created_at,
-	    r.updated_at
+	    r.updated_at,
+	    (
+	        SELECT to_jsonb(p)
+	        FROM dms AS d
+	        INNER JOIN profiles AS p ON p.user_id = CASE WHEN d.user_a = $1 THEN d.user_b ELSE d.user_a END
+	        WHERE d.room_id = r.id
+	    ) AS peer,
+	    jsonb_build_object(
+	        'favorite', ur.favorite,
+	        'muted', ur.muted,
+	        'hidden', ur.hidden,
+	        'position', ur.position
+	    ) AS settings
 	FROM users_rooms AS ur
 	LEFT JOIN rooms AS r ON ur.room_id = r.id
 	LEFT JOIN messages AS m ON r.id = m.room_id
 	WHERE ur.user_id = $1
-	GROUP BY r.id, r.host, r.name, r.created_at, r.updated_at
-	ORDER BY COALESCE(MAX(m.created_at), r.created_at) DESC;
+	  AND ($3 OR NOT ur.hidden)
+	  AND (r.workspace_id = $4 OR r.workspace_id IS NULL)
+	  AND (
+	    $2 = 'include'
+	    OR ($2 = 'exclude' AND r.archived_at IS NULL)
+	    OR ($2 = 'only' AND r.archived_at IS NOT NULL)
+	  )
+	GROUP BY r.id, ur.user_id, ur.room_id
+	ORDER BY ur.favorite DESC, ur.position ASC NULLS LAST, COALESCE(MAX(m.created_at), r.created_at) DESC;
 	`
 
 	rows, err := utils.Retry(ctx, func(ctx context.Context) (pgx.Rows, error) {
-		return p.Pool.Query(ctx, query, userId)
+		return p.Pool.Query(ctx, query, userId, options.Archived, options.IncludeHidden, workspaceId)
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	rooms, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.Room, error) {
-		room, err := pgx.RowToStructByName[models.Room](row)
+	rooms, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (types.UserRoom, error) {
+		room, err := pgx.RowToStructByName[types.UserRoom](row)
 		if err != nil {
-			return models.Room{}, err
+			return types.UserRoom{}, err
 		}
 
 		return room, nil
@@ -89,11 +125,31 @@ func (p *Postgres) GetRoomsByUserId(ctx context.Context, userId uuid.UUID) ([]mo
 	return rooms, nil
 }
 
+// DeleteRoomById deletes the room if the user hosts it. The host and admins at the time are kept as readers of the
+// room's audit log, which outlives the room.
 func (p *Postgres) DeleteRoomById(ctx context.Context, roomId uuid.UUID, userId uuid.UUID) error {
+	const readersQuery string = `
+	INSERT INTO room_audit_readers (room_id, user_id)
+	SELECT ur.room_id, ur.user_id
+	FROM users_rooms AS ur
+	INNER JOIN rooms AS r ON ur.room_id = r.id
+	WHERE ur.room_id = $1 AND (r.host = ur.user_id OR ur.role = 'admin')
+	ON CONFLICT DO NOTHING
+	`
 	const query string = `DELETE FROM rooms WHERE id = $1 AND host = $2`
 
 	_, err := utils.Retry(ctx, func(ctx context.Context) (struct{}, error) {
-		ct, err := p.Pool.Exec(ctx, query, roomId, userId)
+		tx, err := p.Pool.Begin(ctx)
+		if err != nil {
+			return struct{}{}, err
+		}
+		defer func() { _ = tx.Rollback(ctx) }()
+
+		if _, err := tx.Exec(ctx, readersQuery, roomId); err != nil {
+			return struct{}{}, err
+		}
+
+		ct, err := tx.Exec(ctx, query, roomId, userId)
 		if err != nil {
 			return struct{}{}, err
 		}
@@ -105,7 +161,7 @@ func (p *Postgres) DeleteRoomById(ctx context.Context, roomId uuid.UUID, userId
 			}))
 		}
 
-		return struct{}{}, nil
+		return struct{}{}, tx.Commit(ctx)
 	})
 
 	return err
@@ -113,7 +169,7 @@ func (p *Postgres) DeleteRoomById(ctx context.Context, roomId uuid.UUID, userId
 
 func (p *Postgres) GetProfilesByRoomId(ctx context.Context, roomId uuid.UUID, userId uuid.UUID) ([]models.Profile, error) {
 	const query string = `
-	SELECT p.user_id, p.username, p.first_name, p.last_name, p.created_at, p.updated_at
+	SELECT ` + prefixedProfileColumns + `
 	FROM users_rooms AS ur
 	INNER JOIN profiles AS p on ur.user_id = p.user_id
 	WHERE ur.room_id = $1
@@ -143,3 +199,165 @@ func (p *Postgres) GetProfilesByRoomId(ctx context.Context, roomId uuid.UUID, us
 
 	return profiles, nil
 }
+
+func (p *Postgres) DiscoverRooms(ctx context.Context, options types.DiscoverRoomsOptions, workspaceId uuid.UUID) ([]models.Room, error) {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+
+	builder := psql.
+		Select(roomColumns).
+		From("rooms").
+		Where("visibility = ?", models.RoomVisibilityPublic).
+		Where("kind = ?", models.RoomKindGroup).
+		Where("archived_at IS NULL").
+		Where("workspace_id = ?", workspaceId.String())
+
+	if options.Name != "" {
+		builder = builder.Where("name ILIKE ?", "%"+options.Name+"%")
+	}
+
+	builder = builder.
+		OrderBy("name ASC", "id ASC").
+		Limit(uint64(options.Limit)).
+		Offset(uint64(options.Offset))
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := utils.Retry(ctx, func(ctx context.Context) (pgx.Rows, error) {
+		return p.Pool.Query(ctx, query, args...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rooms, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.Room, error) {
+		room, err := pgx.RowToStructByName[models.Room](row)
+		if err != nil {
+			return models.Room{}, err
+		}
+
+		return room, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rooms, nil
+}
+
+func (p *Postgres) PatchRoomById(ctx context.Context, partialRoom types.PartialRoom, roomId uuid.UUID) (models.Room, error) {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+
+	builder := psql.Update("rooms")
+
+	if partialRoom.Name != nil {
+		builder = builder.Set("name", *partialRoom.Name)
+	}
+
+	if partialRoom.Topic != nil {
+		builder = builder.Set("topic", *partialRoom.Topic)
+	}
+
+	if partialRoom.Description != nil {
+		builder = builder.Set("description", *partialRoom.Description)
+	}
+
+	if partialRoom.Avatar != nil {
+		builder = builder.Set("avatar", *partialRoom.Avatar)
+	}
+
+	if partialRoom.PostingPolicy != nil {
+		builder = builder.Set("posting_policy", *partialRoom.PostingPolicy)
+	}
+
+	if partialRoom.SlowModeSeconds != nil {
+		builder = builder.Set("slow_mode_seconds", *partialRoom.SlowModeSeconds)
+	}
+
+	builder = builder.Set("updated_at", partialRoom.UpdatedAt)
+	builder = builder.Where("id = ?", roomId.String())
+	builder = builder.Suffix("RETURNING " + roomColumns)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return models.Room{}, err
+	}
+
+	rows, err := utils.Retry(ctx, func(ctx context.Context) (pgx.Rows, error) {
+		return p.Pool.Query(ctx, query, args...)
+	})
+	if err != nil {
+		return models.Room{}, err
+	}
+
+	room, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.Room])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Room{}, xerrors.NotFoundError("room", map[string]string{
+				"id": roomId.String(),
+			})
+		}
+
+		return models.Room{}, err
+	}
+
+	return room, nil
+}
+
+func (p *Postgres) TransferRoomHost(ctx context.Context, roomId uuid.UUID, hostId uuid.UUID, newHostId uuid.UUID, updatedAt time.Time) (models.Room, error) {
+	const query string = `
+	UPDATE rooms SET host = $3, updated_at = $4
+	WHERE id = $1 AND host = $2
+	  AND EXISTS (
+	    SELECT 1 FROM users_rooms WHERE room_id = $1 AND user_id = $3
+	  )
+	RETURNING ` + roomColumns
+
+	rows, err := utils.Retry(ctx, func(ctx context.Context) (pgx.Rows, error) {
+		return p.Pool.Query(ctx, query, roomId, hostId, newHostId, updatedAt)
+	})
+	if err != nil {
+		return models.Room{}, err
+	}
+
+	room, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.Room])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Room{}, xerrors.NotFoundError("room", map[string]string{
+				"id":     roomId.String(),
+				"host":   hostId.String(),
+				"member": newHostId.String(),
+			})
+		}
+
+		return models.Room{}, err
+	}
+
+	return room, nil
+}
+
+func (p *Postgres) SetRoomArchivedAt(ctx context.Context, roomId uuid.UUID, archivedAt *time.Time, updatedAt time.Time) (models.Room, error) {
+	const query string = `UPDATE rooms SET archived_at = $2, updated_at = $3 WHERE id = $1 RETURNING ` + roomColumns
+
+	rows, err := utils.Retry(ctx, func(ctx context.Context) (pgx.Rows, error) {
+		return p.Pool.Query(ctx, query, roomId, archivedAt, updatedAt)
+	})
+	if err != nil {
+		return models.Room{}, err
+	}
+
+	room, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.Room])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Room{}, xerrors.NotFoundError("room", map[string]string{
+				"id": roomId.String(),
+			})
+		}
+
+		return models.Room{}, err
+	}
+
+	return room, nil
+}
@@ -0,0 +1,166 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"go-chat/internal/constants"
+	"go-chat/internal/models"
+	"go-chat/internal/utils"
+	"go-chat/internal/xerrors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// BlockUser blocks the user and removes any contact or pending contact request between the two
+func (p *Postgres) BlockUser(ctx context.Context, block models.Block) error {
+	const query string = `INSERT INTO user_blocks (blocker, blocked, created_at) VALUES ($1, $2, $3)`
+	const contactsQuery string = `
+	DELETE FROM contacts
+	WHERE (requester = $1 AND recipient = $2) OR (requester = $2 AND recipient = $1)
+	`
+
+	_, err := utils.Retry(ctx, func(ctx context.Context) (struct{}, error) {
+		tx, err := p.Pool.Begin(ctx)
+		if err != nil {
+			return struct{}{}, err
+		}
+		defer func() { _ = tx.Rollback(ctx) }()
+
+		if _, err := tx.Exec(ctx, query, block.Blocker, block.Blocked, block.CreatedAt); err != nil {
+			if xerrors.IsUniqueViolation(err, constants.UserBlocksPKeyUniqueConstraint) {
+				return struct{}{}, utils.CreateNonRetryableError(xerrors.ConflictError("block", "blocked", block.Blocked.String()))
+			}
+
+			if xerrors.IsForeignKeyViolation(err, constants.UserBlocksBlockedFKeyConstraint) {
+				return struct{}{}, utils.CreateNonRetryableError(xerrors.NotFoundError("profile", map[string]string{
+					"user_id": block.Blocked.String(),
+				}))
+			}
+
+			return struct{}{}, err
+		}
+
+		if _, err := tx.Exec(ctx, contactsQuery, block.Blocker, block.Blocked); err != nil {
+			return struct{}{}, err
+		}
+
+		return struct{}{}, tx.Commit(ctx)
+	})
+
+	return err
+}
+
+func (p *Postgres) UnblockUser(ctx context.Context, blockerId uuid.UUID, blockedId uuid.UUID) error {
+	const query string = `DELETE FROM user_blocks WHERE blocker = $1 AND blocked = $2`
+
+	_, err := utils.Retry(ctx, func(ctx context.Context) (struct{}, error) {
+		ct, err := p.Pool.Exec(ctx, query, blockerId, blockedId)
+		if err != nil {
+			return struct{}{}, err
+		}
+
+		if ct.RowsAffected() == 0 {
+			return struct{}{}, utils.CreateNonRetryableError(xerrors.NotFoundError("block", map[string]string{
+				"blocker": blockerId.String(),
+				"blocked": blockedId.String(),
+			}))
+		}
+
+		return struct{}{}, nil
+	})
+
+	return err
+}
+
+func (p *Postgres) GetBlockedProfiles(ctx context.Context, userId uuid.UUID) ([]models.Profile, error) {
+	const query string = `
+	SELECT ` + prefixedProfileColumns + `
+	FROM user_blocks AS b
+	INNER JOIN profiles AS p ON b.blocked = p.user_id
+	WHERE b.blocker = $1
+	ORDER BY b.created_at DESC
+	`
+
+	rows, err := utils.Retry(ctx, func(ctx context.Context) (pgx.Rows, error) {
+		return p.Pool.Query(ctx, query, userId)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	profiles, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.Profile, error) {
+		profile, err := pgx.RowToStructByName[models.Profile](row)
+		if err != nil {
+			return models.Profile{}, err
+		}
+
+		return profile, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return profiles, nil
+}
+
+// CheckUsersBlocked reports whether either user has blocked the other
+func (p *Postgres) CheckUsersBlocked(ctx context.Context, userId uuid.UUID, otherId uuid.UUID) (bool, error) {
+	const query string = `
+	SELECT 1 FROM user_blocks
+	WHERE (blocker = $1 AND blocked = $2) OR (blocker = $2 AND blocked = $1)
+	LIMIT 1
+	`
+
+	var exists int
+	return utils.Retry(ctx, func(ctx context.Context) (bool, error) {
+		if err := p.Pool.QueryRow(ctx, query, userId, otherId).Scan(&exists); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return false, nil
+			}
+
+			return false, err
+		}
+
+		return true, nil
+	})
+}
+
+// GetRoomBlockerIds returns the members of the room who have blocked the user
+func (p *Postgres) GetRoomBlockerIds(ctx context.Context, roomId uuid.UUID, userId uuid.UUID) ([]uuid.UUID, error) {
+	const query string = `
+	SELECT b.blocker
+	FROM user_blocks AS b
+	INNER JOIN users_rooms AS ur ON b.blocker = ur.user_id
+	WHERE ur.room_id = $1 AND b.blocked = $2
+	`
+
+	rows, err := utils.Retry(ctx, func(ctx context.Context) (pgx.Rows, error) {
+		return p.Pool.Query(ctx, query, roomId, userId)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+}
+
+// GetMentionBlockers returns the usernames, out of the given lowercased usernames, whose users have blocked the user
+func (p *Postgres) GetMentionBlockers(ctx context.Context, userId uuid.UUID, usernames []string) ([]string, error) {
+	const query string = `
+	SELECT p.username
+	FROM user_blocks AS b
+	INNER JOIN profiles AS p ON b.blocker = p.user_id
+	WHERE b.blocked = $1 AND lower(p.username) = ANY($2)
+	`
+
+	rows, err := utils.Retry(ctx, func(ctx context.Context) (pgx.Rows, error) {
+		return p.Pool.Query(ctx, query, userId, usernames)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
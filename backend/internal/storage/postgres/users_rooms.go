@@ -4,52 +4,92 @@ import (
 	"context"
 	"errors"
 
+	"go-chat/internal/models"
 	"go-chat/internal/types"
 	"go-chat/internal/utils"
+	"go-chat/internal/xerrors"
 
+	"github.com/Masterminds/squirrel"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgconn"
 )
 
-func (p *Postgres) AddUsersToRoom(ctx context.Context, userIds []uuid.UUID, roomId uuid.UUID) (types.BulkResult[uuid.UUID], error) {
-	// consider using a trigger to enforce the existence check
+func (p *Postgres) CheckUserInRoom(ctx context.Context, roomId uuid.UUID, userId uuid.UUID) (bool, error) {
+	const query string = `SELECT 1 FROM users_rooms WHERE user_id = $1 AND room_id = $2`
+
+	var exists int
+	return utils.Retry(ctx, func(ctx context.Context) (bool, error) {
+		if err := p.Pool.QueryRow(ctx, query, userId, roomId).Scan(&exists); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return false, nil
+			}
+
+			return false, err
+		}
+
+		return true, nil
+	})
+}
+
+func (p *Postgres) JoinPublicRoom(ctx context.Context, roomId uuid.UUID, userId uuid.UUID) error {
 	const query string = `
 	INSERT INTO users_rooms (user_id, room_id)
-	SELECT $1, $2
-	WHERE EXISTS (
-		SELECT 1 FROM profiles WHERE user_id = $1
-	)
+	SELECT $1, id FROM rooms
+	WHERE id = $2 AND visibility = 'public' AND archived_at IS NULL
+	  AND EXISTS (
+	    SELECT 1 FROM workspace_members WHERE workspace_id = rooms.workspace_id AND user_id = $1
+	  )
+	  AND NOT EXISTS (
+	    SELECT 1 FROM room_bans WHERE room_id = rooms.id AND user_id = $1
+	  )
 	ON CONFLICT DO NOTHING
 	`
 
-	bulkResult := types.BulkResult[uuid.UUID]{}
-	batch := &pgx.Batch{}
-	for _, userId := range userIds {
-		batch.Queue(query, userId, roomId).Exec(func(ct pgconn.CommandTag) error {
-			if ct.RowsAffected() == 0 {
-				bulkResult.Failures = append(bulkResult.Failures, types.Failure[uuid.UUID]{
-					Item:    userId,
-					Message: "failed to add user to room",
-				})
-			} else {
-				bulkResult.Successes = append(bulkResult.Successes, userId)
+	_, err := utils.Retry(ctx, func(ctx context.Context) (struct{}, error) {
+		ct, err := p.Pool.Exec(ctx, query, userId, roomId)
+		if err != nil {
+			return struct{}{}, err
+		}
+
+		if ct.RowsAffected() == 0 {
+			inRoom, err := p.CheckUserInRoom(ctx, roomId, userId)
+			if err != nil {
+				return struct{}{}, err
 			}
 
-			return nil
-		})
-	}
+			if inRoom {
+				return struct{}{}, utils.CreateNonRetryableError(xerrors.ConflictError("membership", "room_id", roomId.String()))
+			}
 
-	results := p.Pool.SendBatch(ctx, batch)
-	if err := results.Close(); err != nil {
-		return types.BulkResult[uuid.UUID]{}, err
-	}
+			banned, err := p.CheckUserBannedFromRoom(ctx, roomId, userId)
+			if err != nil {
+				return struct{}{}, err
+			}
+
+			if banned {
+				return struct{}{}, utils.CreateNonRetryableError(xerrors.RoomBannedError())
+			}
 
-	return bulkResult, nil
+			// private rooms are reported as missing so they stay invisible to non members
+			return struct{}{}, utils.CreateNonRetryableError(xerrors.NotFoundError("room", map[string]string{
+				"id": roomId.String(),
+			}))
+		}
+
+		return struct{}{}, nil
+	})
+
+	return err
 }
 
-func (p *Postgres) CheckUserInRoom(ctx context.Context, roomId uuid.UUID, userId uuid.UUID) (bool, error) {
-	const query string = `SELECT 1 FROM users_rooms WHERE user_id = $1 AND room_id = $2`
+func (p *Postgres) CheckUserIsRoomAdmin(ctx context.Context, roomId uuid.UUID, userId uuid.UUID) (bool, error) {
+	const query string = `
+	SELECT 1
+	FROM users_rooms AS ur
+	INNER JOIN rooms AS r ON ur.room_id = r.id
+	WHERE ur.user_id = $1 AND ur.room_id = $2
+	  AND (r.host = $1 OR ur.role = 'admin')
+	`
 
 	var exists int
 	return utils.Retry(ctx, func(ctx context.Context) (bool, error) {
@@ -64,3 +104,192 @@ func (p *Postgres) CheckUserInRoom(ctx context.Context, roomId uuid.UUID, userId
 		return true, nil
 	})
 }
+
+func (p *Postgres) SetRoomMemberRole(ctx context.Context, roomId uuid.UUID, memberId uuid.UUID, role models.RoomRole, hostId uuid.UUID) error {
+	const query string = `
+	UPDATE users_rooms SET role = $3
+	WHERE room_id = $1 AND user_id = $2
+	  AND EXISTS (
+	    SELECT 1 FROM rooms WHERE id = $1 AND host = $4
+	  )
+	`
+
+	_, err := utils.Retry(ctx, func(ctx context.Context) (struct{}, error) {
+		ct, err := p.Pool.Exec(ctx, query, roomId, memberId, role, hostId)
+		if err != nil {
+			return struct{}{}, err
+		}
+
+		if ct.RowsAffected() == 0 {
+			return struct{}{}, utils.CreateNonRetryableError(xerrors.NotFoundError("membership", map[string]string{
+				"room_id": roomId.String(),
+				"user_id": memberId.String(),
+				"host":    hostId.String(),
+			}))
+		}
+
+		return struct{}{}, nil
+	})
+
+	return err
+}
+
+func (p *Postgres) PatchRoomSettings(ctx context.Context, partialSettings types.PartialRoomSettings, roomId uuid.UUID, userId uuid.UUID) (models.RoomSettings, error) {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+
+	builder := psql.Update("users_rooms")
+
+	if partialSettings.Favorite != nil {
+		builder = builder.Set("favorite", *partialSettings.Favorite)
+	}
+
+	if partialSettings.Muted != nil {
+		builder = builder.Set("muted", *partialSettings.Muted)
+	}
+
+	if partialSettings.Hidden != nil {
+		builder = builder.Set("hidden", *partialSettings.Hidden)
+	}
+
+	if partialSettings.Position != nil {
+		if *partialSettings.Position == 0 {
+			builder = builder.Set("position", nil)
+		} else {
+			builder = builder.Set("position", *partialSettings.Position)
+		}
+	}
+
+	builder = builder.
+		Where("room_id = ?", roomId.String()).
+		Where("user_id = ?", userId.String()).
+		Suffix("RETURNING favorite, muted, hidden, position")
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return models.RoomSettings{}, err
+	}
+
+	rows, err := utils.Retry(ctx, func(ctx context.Context) (pgx.Rows, error) {
+		return p.Pool.Query(ctx, query, args...)
+	})
+	if err != nil {
+		return models.RoomSettings{}, err
+	}
+
+	settings, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.RoomSettings])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.RoomSettings{}, xerrors.NotFoundError("membership", map[string]string{
+				"room_id": roomId.String(),
+				"user_id": userId.String(),
+			})
+		}
+
+		return models.RoomSettings{}, err
+	}
+
+	return settings, nil
+}
+
+// GetRoomPeerIds returns the distinct users who share at least one room or dm
+// with the user, leaving out anyone the user has blocked or been blocked by
+func (p *Postgres) GetRoomPeerIds(ctx context.Context, userId uuid.UUID) ([]uuid.UUID, error) {
+	const query string = `
+	SELECT DISTINCT peer.user_id
+	FROM users_rooms AS self
+	INNER JOIN users_rooms AS peer ON peer.room_id = self.room_id
+	WHERE self.user_id = $1 AND peer.user_id <> $1
+	AND NOT EXISTS (
+		SELECT 1 FROM user_blocks
+		WHERE (blocker = $1 AND blocked = peer.user_id) OR (blocker = peer.user_id AND blocked = $1)
+	)
+	`
+
+	rows, err := utils.Retry(ctx, func(ctx context.Context) (pgx.Rows, error) {
+		return p.Pool.Query(ctx, query, userId)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+}
+
+// GetRoomMemberIds returns the ids of every member of the room
+func (p *Postgres) GetRoomMemberIds(ctx context.Context, roomId uuid.UUID) ([]uuid.UUID, error) {
+	const query string = `SELECT user_id FROM users_rooms WHERE room_id = $1`
+
+	rows, err := utils.Retry(ctx, func(ctx context.Context) (pgx.Rows, error) {
+		return p.Pool.Query(ctx, query, roomId)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+}
+
+// GetRoomNotificationTargets returns every member of the room except the author, with whether they muted the room
+func (p *Postgres) GetRoomNotificationTargets(ctx context.Context, roomId uuid.UUID, authorId uuid.UUID) ([]types.NotificationTarget, error) {
+	const query string = `
+	SELECT ur.user_id, p.username, ur.muted
+	FROM users_rooms AS ur
+	INNER JOIN profiles AS p ON ur.user_id = p.user_id
+	WHERE ur.room_id = $1 AND ur.user_id != $2
+	`
+
+	rows, err := utils.Retry(ctx, func(ctx context.Context) (pgx.Rows, error) {
+		return p.Pool.Query(ctx, query, roomId, authorId)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByName[types.NotificationTarget])
+}
+
+// GetRoomMembershipsByUserId returns every room the user belongs to across all workspaces, including hidden and
+// archived rooms and dms
+func (p *Postgres) GetRoomMembershipsByUserId(ctx context.Context, userId uuid.UUID) ([]types.UserRoom, error) {
+	const query string = `
+	SELECT
+	    ` + prefixedRoomColumns + `,
+	    (
+	        SELECT to_jsonb(p)
+	        FROM dms AS d
+	        INNER JOIN profiles AS p ON p.user_id = CASE WHEN d.user_a = $1 THEN d.user_b ELSE d.user_a END
+	        WHERE d.room_id = r.id
+	    ) AS peer,
+	    jsonb_build_object(
+	        'favorite', ur.favorite,
+	        'muted', ur.muted,
+	        'hidden', ur.hidden,
+	        'position', ur.position
+	    ) AS settings
+	FROM users_rooms AS ur
+	INNER JOIN rooms AS r ON ur.room_id = r.id
+	WHERE ur.user_id = $1
+	ORDER BY ur.joined_at ASC
+	`
+
+	rows, err := utils.Retry(ctx, func(ctx context.Context) (pgx.Rows, error) {
+		return p.Pool.Query(ctx, query, userId)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rooms, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (types.UserRoom, error) {
+		room, err := pgx.RowToStructByName[types.UserRoom](row)
+		if err != nil {
+			return types.UserRoom{}, err
+		}
+
+		return room, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rooms, nil
+}
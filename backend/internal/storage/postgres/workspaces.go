@@ -0,0 +1,310 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"go-chat/internal/models"
+	"go-chat/internal/types"
+	"go-chat/internal/utils"
+	"go-chat/internal/xerrors"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	workspaceColumns         = "id, name, dms_contacts_only, created_at, updated_at"
+	prefixedWorkspaceColumns = "w.id, w.name, w.dms_contacts_only, w.created_at, w.updated_at"
+)
+
+func (p *Postgres) CreateWorkspace(ctx context.Context, workspace models.Workspace, ownerId uuid.UUID) error {
+	const workspacesQuery string = `INSERT INTO workspaces (id, name, dms_contacts_only, created_at, updated_at) VALUES ($1, $2, $3, $4, $5)`
+	const membersQuery string = `INSERT INTO workspace_members (workspace_id, user_id, role) VALUES ($1, $2, 'owner')`
+
+	batch := &pgx.Batch{}
+	batch.Queue(workspacesQuery, workspace.Id, workspace.Name, workspace.DmsContactsOnly, workspace.CreatedAt, workspace.UpdatedAt)
+	batch.Queue(membersQuery, workspace.Id, ownerId)
+
+	results := p.Pool.SendBatch(ctx, batch)
+
+	return results.Close()
+}
+
+func (p *Postgres) GetWorkspacesByUserId(ctx context.Context, userId uuid.UUID) ([]types.UserWorkspace, error) {
+	const query string = `
+	SELECT ` + prefixedWorkspaceColumns + `, wm.role
+	FROM workspace_members AS wm
+	INNER JOIN workspaces AS w ON wm.workspace_id = w.id
+	WHERE wm.user_id = $1
+	ORDER BY wm.joined_at ASC
+	`
+
+	rows, err := utils.Retry(ctx, func(ctx context.Context) (pgx.Rows, error) {
+		return p.Pool.Query(ctx, query, userId)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	workspaces, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (types.UserWorkspace, error) {
+		workspace, err := pgx.RowToStructByName[types.UserWorkspace](row)
+		if err != nil {
+			return types.UserWorkspace{}, err
+		}
+
+		return workspace, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return workspaces, nil
+}
+
+func (p *Postgres) GetWorkspaceById(ctx context.Context, workspaceId uuid.UUID) (models.Workspace, error) {
+	const query string = `SELECT ` + workspaceColumns + ` FROM workspaces WHERE id = $1`
+
+	rows, err := utils.Retry(ctx, func(ctx context.Context) (pgx.Rows, error) {
+		return p.Pool.Query(ctx, query, workspaceId)
+	})
+	if err != nil {
+		return models.Workspace{}, err
+	}
+
+	workspace, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.Workspace])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Workspace{}, xerrors.NotFoundError("workspace", map[string]string{
+				"id": workspaceId.String(),
+			})
+		}
+
+		return models.Workspace{}, err
+	}
+
+	return workspace, nil
+}
+
+// PatchWorkspace updates the workspace if the user owns it
+func (p *Postgres) PatchWorkspace(ctx context.Context, partialWorkspace types.PartialWorkspace, workspaceId uuid.UUID, ownerId uuid.UUID) (models.Workspace, error) {
+	psql := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+
+	builder := psql.Update("workspaces")
+
+	if partialWorkspace.Name != nil {
+		builder = builder.Set("name", *partialWorkspace.Name)
+	}
+
+	if partialWorkspace.DmsContactsOnly != nil {
+		builder = builder.Set("dms_contacts_only", *partialWorkspace.DmsContactsOnly)
+	}
+
+	builder = builder.
+		Set("updated_at", partialWorkspace.UpdatedAt).
+		Where("id = ?", workspaceId.String()).
+		Where(
+			squirrel.Expr(
+				`EXISTS (
+					SELECT 1 FROM workspace_members
+					WHERE workspace_members.workspace_id = workspaces.id
+						AND workspace_members.user_id = ?
+						AND workspace_members.role = 'owner'
+				)`,
+				ownerId.String(),
+			),
+		).
+		Suffix("RETURNING " + workspaceColumns)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return models.Workspace{}, err
+	}
+
+	rows, err := utils.Retry(ctx, func(ctx context.Context) (pgx.Rows, error) {
+		return p.Pool.Query(ctx, query, args...)
+	})
+	if err != nil {
+		return models.Workspace{}, err
+	}
+
+	workspace, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[models.Workspace])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Workspace{}, xerrors.NotFoundError("workspace", map[string]string{
+				"id": workspaceId.String(),
+			})
+		}
+
+		return models.Workspace{}, err
+	}
+
+	return workspace, nil
+}
+
+func (p *Postgres) GetDefaultWorkspaceId(ctx context.Context, userId uuid.UUID) (uuid.UUID, bool, error) {
+	const query string = `
+	SELECT workspace_id FROM workspace_members
+	WHERE user_id = $1
+	ORDER BY joined_at ASC, workspace_id ASC
+	LIMIT 1
+	`
+
+	type result struct {
+		workspaceId uuid.UUID
+		found       bool
+	}
+
+	res, err := utils.Retry(ctx, func(ctx context.Context) (result, error) {
+		var workspaceId uuid.UUID
+		if err := p.Pool.QueryRow(ctx, query, userId).Scan(&workspaceId); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return result{}, nil
+			}
+
+			return result{}, err
+		}
+
+		return result{workspaceId: workspaceId, found: true}, nil
+	})
+
+	return res.workspaceId, res.found, err
+}
+
+func (p *Postgres) CheckUserInWorkspace(ctx context.Context, workspaceId uuid.UUID, userId uuid.UUID) (bool, error) {
+	const query string = `SELECT 1 FROM workspace_members WHERE user_id = $1 AND workspace_id = $2`
+
+	var exists int
+	return utils.Retry(ctx, func(ctx context.Context) (bool, error) {
+		if err := p.Pool.QueryRow(ctx, query, userId, workspaceId).Scan(&exists); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return false, nil
+			}
+
+			return false, err
+		}
+
+		return true, nil
+	})
+}
+
+func (p *Postgres) AddUsersToWorkspace(ctx context.Context, userIds []uuid.UUID, workspaceId uuid.UUID, ownerId uuid.UUID) (types.BulkResult[uuid.UUID], error) {
+	const query string = `
+	INSERT INTO workspace_members (workspace_id, user_id)
+	SELECT $1, $2
+	WHERE EXISTS (
+		SELECT 1 FROM profiles WHERE user_id = $2
+	)
+	AND EXISTS (
+		SELECT 1 FROM workspace_members WHERE workspace_id = $1 AND user_id = $3 AND role = 'owner'
+	)
+	ON CONFLICT DO NOTHING
+	`
+
+	bulkResult := types.BulkResult[uuid.UUID]{}
+	batch := &pgx.Batch{}
+	for _, userId := range userIds {
+		batch.Queue(query, workspaceId, userId, ownerId).Exec(func(ct pgconn.CommandTag) error {
+			if ct.RowsAffected() == 0 {
+				bulkResult.Failures = append(bulkResult.Failures, types.Failure[uuid.UUID]{
+					Item:    userId,
+					Message: "failed to add user to workspace",
+				})
+			} else {
+				bulkResult.Successes = append(bulkResult.Successes, userId)
+			}
+
+			return nil
+		})
+	}
+
+	results := p.Pool.SendBatch(ctx, batch)
+	if err := results.Close(); err != nil {
+		return types.BulkResult[uuid.UUID]{}, err
+	}
+
+	return bulkResult, nil
+}
+
+// RemoveUserFromWorkspace removes a member along with their memberships in the workspace's rooms. Owners can remove
+// any other member and members can remove themselves.
+func (p *Postgres) RemoveUserFromWorkspace(ctx context.Context, workspaceId uuid.UUID, memberId uuid.UUID, actorId uuid.UUID) error {
+	const membersQuery string = `
+	DELETE FROM workspace_members
+	WHERE workspace_id = $1 AND user_id = $2 AND role <> 'owner'
+	  AND (
+	    $2 = $3
+	    OR EXISTS (
+	      SELECT 1 FROM workspace_members WHERE workspace_id = $1 AND user_id = $3 AND role = 'owner'
+	    )
+	  )
+	`
+	const usersRoomsQuery string = `
+	DELETE FROM users_rooms
+	WHERE user_id = $2
+	  AND room_id IN (
+	    SELECT id FROM rooms WHERE workspace_id = $1
+	  )
+	`
+
+	_, err := utils.Retry(ctx, func(ctx context.Context) (struct{}, error) {
+		tx, err := p.Pool.Begin(ctx)
+		if err != nil {
+			return struct{}{}, err
+		}
+		defer func() { _ = tx.Rollback(ctx) }()
+
+		ct, err := tx.Exec(ctx, membersQuery, workspaceId, memberId, actorId)
+		if err != nil {
+			return struct{}{}, err
+		}
+
+		if ct.RowsAffected() == 0 {
+			return struct{}{}, utils.CreateNonRetryableError(xerrors.NotFoundError("workspace member", map[string]string{
+				"workspace_id": workspaceId.String(),
+				"user_id":      memberId.String(),
+			}))
+		}
+
+		if _, err := tx.Exec(ctx, usersRoomsQuery, workspaceId, memberId); err != nil {
+			return struct{}{}, err
+		}
+
+		return struct{}{}, tx.Commit(ctx)
+	})
+
+	return err
+}
+
+func (p *Postgres) GetProfilesByWorkspaceId(ctx context.Context, workspaceId uuid.UUID) ([]models.Profile, error) {
+	const query string = `
+	SELECT ` + prefixedProfileColumns + `
+	FROM workspace_members AS wm
+	INNER JOIN profiles AS p ON wm.user_id = p.user_id
+	WHERE wm.workspace_id = $1
+	ORDER BY p.username ASC
+	`
+
+	rows, err := utils.Retry(ctx, func(ctx context.Context) (pgx.Rows, error) {
+		return p.Pool.Query(ctx, query, workspaceId)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	profiles, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.Profile, error) {
+		profile, err := pgx.RowToStructByName[models.Profile](row)
+		if err != nil {
+			return models.Profile{}, err
+		}
+
+		return profile, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return profiles, nil
+}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"time"
 
 	"go-chat/internal/models"
 	"go-chat/internal/types"
@@ -13,23 +14,106 @@ type Storage interface {
 	Ping(ctx context.Context) error
 
 	// rooms
-	CreateRoom(ctx context.Context, room models.Room, members []uuid.UUID) (types.BulkResult[uuid.UUID], error)
-	GetRoomsByUserId(ctx context.Context, userId uuid.UUID) ([]models.Room, error)
+	CreateRoom(ctx context.Context, room models.Room) error
+	GetRoomById(ctx context.Context, roomId uuid.UUID) (models.Room, error)
+	PatchRoomById(ctx context.Context, partialRoom types.PartialRoom, roomId uuid.UUID) (models.Room, error)
+	SetRoomArchivedAt(ctx context.Context, roomId uuid.UUID, archivedAt *time.Time, updatedAt time.Time) (models.Room, error)
+	TransferRoomHost(ctx context.Context, roomId uuid.UUID, hostId uuid.UUID, newHostId uuid.UUID, updatedAt time.Time) (models.Room, error)
+	GetRoomsByUserId(ctx context.Context, options types.GetRoomsOptions, userId uuid.UUID, workspaceId uuid.UUID) ([]types.UserRoom, error)
 	DeleteRoomById(ctx context.Context, roomId uuid.UUID, userId uuid.UUID) error
 	GetProfilesByRoomId(ctx context.Context, roomId uuid.UUID, userId uuid.UUID) ([]models.Profile, error)
+	DiscoverRooms(ctx context.Context, options types.DiscoverRoomsOptions, workspaceId uuid.UUID) ([]models.Room, error)
 
 	// messages
 	CreateMessage(ctx context.Context, message models.Message) error
 	GetUserMessagesByRoomId(ctx context.Context, roomId uuid.UUID, userId uuid.UUID) ([]types.UserMessage, error)
-	DeleteMessageById(ctx context.Context, messageId uuid.UUID, userId uuid.UUID) error
+	DeleteMessageById(ctx context.Context, messageId uuid.UUID, userId uuid.UUID) (uuid.UUID, error)
+	CreateUserMessage(ctx context.Context, message models.Message, slowMode time.Duration) (*time.Time, error)
+	GetMessagesByAuthor(ctx context.Context, userId uuid.UUID) ([]models.Message, error)
+
+	// dms
+	GetOrCreateDmRoom(ctx context.Context, room models.Room, userId uuid.UUID, peerId uuid.UUID) (types.UserRoom, bool, error)
 
 	// users_rooms
-	AddUsersToRoom(ctx context.Context, userIds []uuid.UUID, roomId uuid.UUID) (types.BulkResult[uuid.UUID], error)
 	CheckUserInRoom(ctx context.Context, roomId uuid.UUID, userId uuid.UUID) (bool, error)
+	JoinPublicRoom(ctx context.Context, roomId uuid.UUID, userId uuid.UUID) error
+	CheckUserIsRoomAdmin(ctx context.Context, roomId uuid.UUID, userId uuid.UUID) (bool, error)
+	PatchRoomSettings(ctx context.Context, partialSettings types.PartialRoomSettings, roomId uuid.UUID, userId uuid.UUID) (models.RoomSettings, error)
+	SetRoomMemberRole(ctx context.Context, roomId uuid.UUID, memberId uuid.UUID, role models.RoomRole, hostId uuid.UUID) error
+	GetRoomPeerIds(ctx context.Context, userId uuid.UUID) ([]uuid.UUID, error)
+	GetRoomMemberIds(ctx context.Context, roomId uuid.UUID) ([]uuid.UUID, error)
+	GetRoomNotificationTargets(ctx context.Context, roomId uuid.UUID, authorId uuid.UUID) ([]types.NotificationTarget, error)
+	GetRoomMembershipsByUserId(ctx context.Context, userId uuid.UUID) ([]types.UserRoom, error)
+
+	// room_bans
+	BanUserFromRoom(ctx context.Context, ban models.RoomBan) error
+	UnbanUserFromRoom(ctx context.Context, roomId uuid.UUID, userId uuid.UUID) error
+	GetBansByRoomId(ctx context.Context, roomId uuid.UUID) ([]models.RoomBan, error)
+	CheckUserBannedFromRoom(ctx context.Context, roomId uuid.UUID, userId uuid.UUID) (bool, error)
+
+	// room_mutes
+	MuteUserInRoom(ctx context.Context, mute models.RoomMute) error
+	UnmuteUserInRoom(ctx context.Context, roomId uuid.UUID, userId uuid.UUID) error
+	GetMutesByRoomId(ctx context.Context, roomId uuid.UUID) ([]models.RoomMute, error)
+	GetRoomMuteExpiry(ctx context.Context, roomId uuid.UUID, userId uuid.UUID) (*time.Time, error)
+
+	// room_audit_log
+	CreateAuditEntry(ctx context.Context, entry models.AuditEntry) error
+	GetAuditLogByRoomId(ctx context.Context, options types.AuditLogOptions, roomId uuid.UUID) ([]models.AuditEntry, error)
+	CheckUserCanReadAuditLog(ctx context.Context, roomId uuid.UUID, userId uuid.UUID) (bool, error)
+
+	// invites
+	CreateInvite(ctx context.Context, invite models.Invite) error
+	GetInvitesByRoomId(ctx context.Context, roomId uuid.UUID) ([]models.Invite, error)
+	DeleteInvite(ctx context.Context, roomId uuid.UUID, code string) error
+	RedeemInvite(ctx context.Context, code string, userId uuid.UUID) (models.Room, error)
+
+	// invitations
+	CreateInvitations(ctx context.Context, invitations []models.Invitation) (types.BulkResult[uuid.UUID], error)
+	GetPendingInvitationsByUserId(ctx context.Context, userId uuid.UUID) ([]types.PendingInvitation, error)
+	AcceptInvitation(ctx context.Context, invitationId uuid.UUID, userId uuid.UUID, updatedAt time.Time) (models.Invitation, error)
+	DeclineInvitation(ctx context.Context, invitationId uuid.UUID, userId uuid.UUID, updatedAt time.Time) error
+
+	// workspaces
+	CreateWorkspace(ctx context.Context, workspace models.Workspace, ownerId uuid.UUID) error
+	GetWorkspacesByUserId(ctx context.Context, userId uuid.UUID) ([]types.UserWorkspace, error)
+	GetWorkspaceById(ctx context.Context, workspaceId uuid.UUID) (models.Workspace, error)
+	PatchWorkspace(ctx context.Context, partialWorkspace types.PartialWorkspace, workspaceId uuid.UUID, ownerId uuid.UUID) (models.Workspace, error)
+	GetDefaultWorkspaceId(ctx context.Context, userId uuid.UUID) (uuid.UUID, bool, error)
+	CheckUserInWorkspace(ctx context.Context, workspaceId uuid.UUID, userId uuid.UUID) (bool, error)
+	AddUsersToWorkspace(ctx context.Context, userIds []uuid.UUID, workspaceId uuid.UUID, ownerId uuid.UUID) (types.BulkResult[uuid.UUID], error)
+	RemoveUserFromWorkspace(ctx context.Context, workspaceId uuid.UUID, memberId uuid.UUID, actorId uuid.UUID) error
+	GetProfilesByWorkspaceId(ctx context.Context, workspaceId uuid.UUID) ([]models.Profile, error)
+
+	// user_blocks
+	BlockUser(ctx context.Context, block models.Block) error
+	UnblockUser(ctx context.Context, blockerId uuid.UUID, blockedId uuid.UUID) error
+	GetBlockedProfiles(ctx context.Context, userId uuid.UUID) ([]models.Profile, error)
+	CheckUsersBlocked(ctx context.Context, userId uuid.UUID, otherId uuid.UUID) (bool, error)
+	GetRoomBlockerIds(ctx context.Context, roomId uuid.UUID, userId uuid.UUID) ([]uuid.UUID, error)
+	GetMentionBlockers(ctx context.Context, userId uuid.UUID, usernames []string) ([]string, error)
+
+	// contacts
+	CreateContactRequest(ctx context.Context, contact models.Contact, cooldown time.Duration) (models.Contact, error)
+	AcceptContactRequest(ctx context.Context, requesterId uuid.UUID, recipientId uuid.UUID) (models.Contact, error)
+	DeleteContactRequest(ctx context.Context, userId uuid.UUID, otherId uuid.UUID, declinedAt time.Time) error
+	DeleteContact(ctx context.Context, userId uuid.UUID, contactId uuid.UUID) error
+	GetContactProfiles(ctx context.Context, userId uuid.UUID) ([]models.Profile, error)
+	GetContactRequests(ctx context.Context, userId uuid.UUID) ([]types.ContactRequest, error)
+	CheckUsersAreContacts(ctx context.Context, userId uuid.UUID, otherId uuid.UUID) (bool, error)
 
 	// profiles
 	GetProfileByUserId(ctx context.Context, userId uuid.UUID) (models.Profile, error)
+	GetDetailedProfileByUserId(ctx context.Context, userId uuid.UUID) (types.DetailedProfile, error)
 	PatchProfileByUserId(ctx context.Context, partialProfile types.PartialProfile, userId uuid.UUID) error
-	CreateProfile(ctx context.Context, profile models.Profile) error
-	SearchProfiles(ctx context.Context, options types.SearchProfilesOptions, userId uuid.UUID) ([]models.Profile, error)
+	CreateProfile(ctx context.Context, profile models.Profile, workspace models.Workspace) error
+	SearchProfiles(ctx context.Context, options types.SearchProfilesOptions, userId uuid.UUID, workspaceId uuid.UUID) (types.SearchProfilesResult, error)
+	SetProfileAvatar(ctx context.Context, userId uuid.UUID, images map[int][]byte, avatarUrl string, updatedAt time.Time) error
+	GetProfileAvatar(ctx context.Context, userId uuid.UUID, size int) (models.Avatar, error)
+	SetProfileLastSeen(ctx context.Context, userId uuid.UUID, lastSeenAt time.Time) error
+	DeleteAccount(ctx context.Context, userId uuid.UUID) error
+
+	// preferences
+	GetPreferences(ctx context.Context, userId uuid.UUID) (models.Preferences, error)
+	PatchPreferences(ctx context.Context, patch types.PreferencesPatch, expectedVersion *int64, userId uuid.UUID) (models.Preferences, error)
 }
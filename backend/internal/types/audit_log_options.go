@@ -0,0 +1,20 @@
+package types
+
+type AuditLogOptions struct {
+	Limit  int `query:"limit"`
+	Offset int `query:"offset"`
+}
+
+func (alo *AuditLogOptions) Validate() map[string]string {
+	errMap := make(map[string]string)
+
+	if alo.Limit < 1 {
+		alo.Limit = defaultLimit
+	}
+
+	if alo.Offset < 0 {
+		alo.Offset = defaultOffset
+	}
+
+	return errMap
+}
@@ -0,0 +1,45 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditLogOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    AuditLogOptions
+		wantErrs map[string]string
+		wantVals AuditLogOptions
+	}{
+		{
+			name:     "valid input",
+			input:    AuditLogOptions{Limit: 50, Offset: 10},
+			wantErrs: map[string]string{},
+			wantVals: AuditLogOptions{Limit: 50, Offset: 10},
+		},
+		{
+			name:     "missing limit and offset",
+			input:    AuditLogOptions{},
+			wantErrs: map[string]string{},
+			wantVals: AuditLogOptions{Limit: defaultLimit, Offset: defaultOffset},
+		},
+		{
+			name:     "invalid limit and offset",
+			input:    AuditLogOptions{Limit: -1, Offset: -5},
+			wantErrs: map[string]string{},
+			wantVals: AuditLogOptions{Limit: defaultLimit, Offset: defaultOffset},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := tt.input.Validate()
+
+			assert.Equal(t, tt.wantErrs, errs, "error map mismatch")
+			assert.Equal(t, tt.wantVals.Limit, tt.input.Limit, "limit mismatch")
+			assert.Equal(t, tt.wantVals.Offset, tt.input.Offset, "offset mismatch")
+		})
+	}
+}
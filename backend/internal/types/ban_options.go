@@ -0,0 +1,21 @@
+package types
+
+import (
+	"fmt"
+
+	"go-chat/internal/constants"
+)
+
+type BanOptions struct {
+	Reason string `json:"reason"`
+}
+
+func (bo *BanOptions) Validate() map[string]string {
+	errMap := make(map[string]string)
+
+	if len(bo.Reason) > constants.MaxRoomBanReasonLength {
+		errMap["reason"] = fmt.Sprintf("reason must be at most %d characters", constants.MaxRoomBanReasonLength)
+	}
+
+	return errMap
+}
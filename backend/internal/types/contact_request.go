@@ -0,0 +1,16 @@
+package types
+
+import "go-chat/internal/models"
+
+type ContactRequestDirection string
+
+const (
+	ContactRequestIncoming ContactRequestDirection = "incoming"
+	ContactRequestOutgoing ContactRequestDirection = "outgoing"
+)
+
+type ContactRequest struct {
+	models.Contact
+	Direction ContactRequestDirection `json:"direction" db:"direction"`
+	Profile   models.Profile          `json:"profile" db:"profile"`
+}
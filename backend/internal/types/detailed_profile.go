@@ -0,0 +1,8 @@
+package types
+
+import "go-chat/internal/models"
+
+type DetailedProfile struct {
+	models.Profile
+	models.ProfileDetails
+}
@@ -0,0 +1,21 @@
+package types
+
+type DiscoverRoomsOptions struct {
+	Name   string `query:"name"`
+	Limit  int    `query:"limit"`
+	Offset int    `query:"offset"`
+}
+
+func (dro *DiscoverRoomsOptions) Validate() map[string]string {
+	errMap := make(map[string]string)
+
+	if dro.Limit < 1 {
+		dro.Limit = defaultLimit
+	}
+
+	if dro.Offset < 0 {
+		dro.Offset = defaultOffset
+	}
+
+	return errMap
+}
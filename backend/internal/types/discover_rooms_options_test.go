@@ -0,0 +1,45 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiscoverRoomsOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    DiscoverRoomsOptions
+		wantErrs map[string]string
+		wantVals DiscoverRoomsOptions
+	}{
+		{
+			name:     "valid input",
+			input:    DiscoverRoomsOptions{Name: "general", Limit: 20, Offset: 5},
+			wantErrs: map[string]string{},
+			wantVals: DiscoverRoomsOptions{Name: "general", Limit: 20, Offset: 5},
+		},
+		{
+			name:     "empty name lists every public room",
+			input:    DiscoverRoomsOptions{Limit: 20, Offset: 0},
+			wantErrs: map[string]string{},
+			wantVals: DiscoverRoomsOptions{Limit: 20, Offset: 0},
+		},
+		{
+			name:     "invalid limit and offset",
+			input:    DiscoverRoomsOptions{Name: "general", Limit: 0, Offset: -5},
+			wantErrs: map[string]string{},
+			wantVals: DiscoverRoomsOptions{Name: "general", Limit: defaultLimit, Offset: defaultOffset},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := tt.input.Validate()
+
+			assert.Equal(t, tt.wantErrs, errs, "error map mismatch")
+			assert.Equal(t, tt.wantVals.Limit, tt.input.Limit, "limit mismatch")
+			assert.Equal(t, tt.wantVals.Offset, tt.input.Offset, "offset mismatch")
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package types
+
+type ArchivedFilter string
+
+const (
+	ArchivedFilterInclude ArchivedFilter = "include"
+	ArchivedFilterExclude ArchivedFilter = "exclude"
+	ArchivedFilterOnly    ArchivedFilter = "only"
+)
+
+type GetRoomsOptions struct {
+	Archived      ArchivedFilter `query:"archived"`
+	IncludeHidden bool           `query:"includeHidden"`
+}
+
+func (gro *GetRoomsOptions) Validate() map[string]string {
+	errMap := make(map[string]string)
+
+	switch gro.Archived {
+	case "":
+		gro.Archived = ArchivedFilterInclude
+	case ArchivedFilterInclude, ArchivedFilterExclude, ArchivedFilterOnly:
+	default:
+		errMap["archived"] = "archived must be one of include, exclude or only"
+	}
+
+	return errMap
+}
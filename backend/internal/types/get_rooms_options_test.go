@@ -0,0 +1,44 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetRoomsOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    GetRoomsOptions
+		wantErrs map[string]string
+		wantVals GetRoomsOptions
+	}{
+		{
+			name:     "defaults to including archived rooms",
+			input:    GetRoomsOptions{},
+			wantErrs: map[string]string{},
+			wantVals: GetRoomsOptions{Archived: ArchivedFilterInclude},
+		},
+		{
+			name:     "only archived rooms",
+			input:    GetRoomsOptions{Archived: ArchivedFilterOnly},
+			wantErrs: map[string]string{},
+			wantVals: GetRoomsOptions{Archived: ArchivedFilterOnly},
+		},
+		{
+			name:     "unknown filter",
+			input:    GetRoomsOptions{Archived: "sometimes"},
+			wantErrs: map[string]string{"archived": "archived must be one of include, exclude or only"},
+			wantVals: GetRoomsOptions{Archived: "sometimes"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := tt.input.Validate()
+
+			assert.Equal(t, tt.wantErrs, errs, "error map mismatch")
+			assert.Equal(t, tt.wantVals.Archived, tt.input.Archived, "archived mismatch")
+		})
+	}
+}
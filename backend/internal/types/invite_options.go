@@ -0,0 +1,22 @@
+package types
+
+import "time"
+
+type InviteOptions struct {
+	MaxUses   *int       `json:"max_uses"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+func (io *InviteOptions) Validate() map[string]string {
+	errMap := make(map[string]string)
+
+	if io.MaxUses != nil && *io.MaxUses < 1 {
+		errMap["max_uses"] = "max uses must be at least 1"
+	}
+
+	if io.ExpiresAt != nil && !io.ExpiresAt.After(time.Now()) {
+		errMap["expires_at"] = "expiry must be in the future"
+	}
+
+	return errMap
+}
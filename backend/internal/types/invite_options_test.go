@@ -0,0 +1,48 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInviteOptions_Validate(t *testing.T) {
+	zero := 0
+	five := 5
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		input    InviteOptions
+		wantErrs map[string]string
+	}{
+		{
+			name:     "no limits",
+			input:    InviteOptions{},
+			wantErrs: map[string]string{},
+		},
+		{
+			name:     "valid limits",
+			input:    InviteOptions{MaxUses: &five, ExpiresAt: &future},
+			wantErrs: map[string]string{},
+		},
+		{
+			name:  "invalid limits",
+			input: InviteOptions{MaxUses: &zero, ExpiresAt: &past},
+			wantErrs: map[string]string{
+				"max_uses":   "max uses must be at least 1",
+				"expires_at": "expiry must be in the future",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := tt.input.Validate()
+
+			assert.Equal(t, tt.wantErrs, errs, "error map mismatch")
+		})
+	}
+}
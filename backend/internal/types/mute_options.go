@@ -0,0 +1,24 @@
+package types
+
+import (
+	"fmt"
+	"time"
+
+	"go-chat/internal/constants"
+)
+
+type MuteOptions struct {
+	// Duration is the length of the mute in seconds
+	Duration int `json:"duration"`
+}
+
+func (mo *MuteOptions) Validate() map[string]string {
+	errMap := make(map[string]string)
+
+	maxSeconds := int(constants.MaxRoomMuteDuration / time.Second)
+	if mo.Duration < 1 || mo.Duration > maxSeconds {
+		errMap["duration"] = fmt.Sprintf("duration must be between 1 and %d seconds", maxSeconds)
+	}
+
+	return errMap
+}
@@ -0,0 +1,43 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMuteOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    MuteOptions
+		wantErrs map[string]string
+	}{
+		{
+			name:     "valid duration",
+			input:    MuteOptions{Duration: 600},
+			wantErrs: map[string]string{},
+		},
+		{
+			name:  "missing duration",
+			input: MuteOptions{},
+			wantErrs: map[string]string{
+				"duration": "duration must be between 1 and 2592000 seconds",
+			},
+		},
+		{
+			name:  "duration too long",
+			input: MuteOptions{Duration: 2592001},
+			wantErrs: map[string]string{
+				"duration": "duration must be between 1 and 2592000 seconds",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := tt.input.Validate()
+
+			assert.Equal(t, tt.wantErrs, errs, "error map mismatch")
+		})
+	}
+}
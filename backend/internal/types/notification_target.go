@@ -0,0 +1,10 @@
+package types
+
+import "github.com/google/uuid"
+
+// NotificationTarget is a room member who may be notified of a new message
+type NotificationTarget struct {
+	UserId   uuid.UUID `db:"user_id"`
+	Username string    `db:"username"`
+	Muted    bool      `db:"muted"`
+}
@@ -2,32 +2,72 @@ package types
 
 import (
 	"fmt"
+	"net/url"
 	"time"
+	_ "time/tzdata"
+	"unicode/utf8"
 
 	"go-chat/internal/constants"
+
+	"golang.org/x/text/language"
 )
 
 type PartialProfile struct {
 	Username  *string   `json:"username,omitempty"`
 	FirstName *string   `json:"first_name,omitempty"`
 	LastName  *string   `json:"last_name,omitempty"`
+	Bio       *string   `json:"bio,omitempty"`
+	Pronouns  *string   `json:"pronouns,omitempty"`
+	Timezone  *string   `json:"timezone,omitempty"`
+	Locale    *string   `json:"locale,omitempty"`
+	Links     *[]string `json:"links,omitempty"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
 func (pp *PartialProfile) Validate() map[string]string {
 	errMap := make(map[string]string)
 
-	if pp.Username == nil && pp.FirstName == nil && pp.LastName == nil {
+	if pp.Username == nil && pp.FirstName == nil && pp.LastName == nil && pp.Bio == nil && pp.Pronouns == nil &&
+		pp.Timezone == nil && pp.Locale == nil && pp.Links == nil {
 		errMap["fields"] = "at least one field must be provided to update the profile"
 		return errMap
 	}
 
-	if pp.Username != nil && (len(*pp.Username) < constants.MinUsernameLength || len(*pp.Username) > constants.MaxUsernameLength) {
-		errMap["username"] = fmt.Sprintf(
-			"username length must be between %d and %d",
-			constants.MinUsernameLength,
-			constants.MaxUsernameLength,
-		)
+	// usernames are checked by the username policy, which counts their length in runes
+
+	if pp.Bio != nil && utf8.RuneCountInString(*pp.Bio) > constants.MaxBioLength {
+		errMap["bio"] = fmt.Sprintf("bio length must be at most %d", constants.MaxBioLength)
+	}
+
+	if pp.Pronouns != nil && utf8.RuneCountInString(*pp.Pronouns) > constants.MaxPronounsLength {
+		errMap["pronouns"] = fmt.Sprintf("pronouns length must be at most %d", constants.MaxPronounsLength)
+	}
+
+	if pp.Timezone != nil && *pp.Timezone != "" {
+		if _, err := time.LoadLocation(*pp.Timezone); err != nil || *pp.Timezone == "Local" {
+			errMap["timezone"] = "timezone must be an IANA time zone name"
+		}
+	}
+
+	if pp.Locale != nil && *pp.Locale != "" {
+		if _, err := language.Parse(*pp.Locale); err != nil {
+			errMap["locale"] = "locale must be a BCP 47 language tag"
+		}
+	}
+
+	if pp.Links != nil {
+		if len(*pp.Links) > constants.MaxProfileLinks {
+			errMap["links"] = fmt.Sprintf("at most %d links are allowed", constants.MaxProfileLinks)
+		} else {
+			for _, link := range *pp.Links {
+				u, err := url.Parse(link)
+				if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+					len(link) > constants.MaxProfileLinkLength {
+					errMap["links"] = fmt.Sprintf("links must be http or https urls of at most %d characters", constants.MaxProfileLinkLength)
+					break
+				}
+			}
+		}
 	}
 
 	return errMap
@@ -0,0 +1,75 @@
+package types
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPartialProfile_Validate(t *testing.T) {
+	bio := "hello there"
+	longBio := strings.Repeat("é", 513)
+	pronouns := "they/them"
+	longPronouns := strings.Repeat("a", 33)
+	timezone := "America/New_York"
+	badTimezone := "Mars/Olympus_Mons"
+	localTimezone := "Local"
+	locale := "en-US"
+	badLocale := "not a locale"
+	empty := ""
+	links := []string{"https://example.com", "http://example.org/me"}
+	badLinks := []string{"https://example.com", "javascript:alert(1)"}
+	tooManyLinks := []string{"https://a.com", "https://b.com", "https://c.com", "https://d.com", "https://e.com", "https://f.com"}
+
+	tests := []struct {
+		name     string
+		input    PartialProfile
+		wantErrs map[string]string
+	}{
+		{
+			name:     "no fields",
+			input:    PartialProfile{},
+			wantErrs: map[string]string{"fields": "at least one field must be provided to update the profile"},
+		},
+		{
+			name:     "valid details",
+			input:    PartialProfile{Bio: &bio, Pronouns: &pronouns, Timezone: &timezone, Locale: &locale, Links: &links},
+			wantErrs: map[string]string{},
+		},
+		{
+			name:     "cleared details",
+			input:    PartialProfile{Bio: &empty, Pronouns: &empty, Timezone: &empty, Locale: &empty, Links: &[]string{}},
+			wantErrs: map[string]string{},
+		},
+		{
+			name:  "invalid details",
+			input: PartialProfile{Bio: &longBio, Pronouns: &longPronouns, Timezone: &badTimezone, Locale: &badLocale, Links: &badLinks},
+			wantErrs: map[string]string{
+				"bio":      "bio length must be at most 512",
+				"pronouns": "pronouns length must be at most 32",
+				"timezone": "timezone must be an IANA time zone name",
+				"locale":   "locale must be a BCP 47 language tag",
+				"links":    "links must be http or https urls of at most 256 characters",
+			},
+		},
+		{
+			name:     "local timezone",
+			input:    PartialProfile{Timezone: &localTimezone},
+			wantErrs: map[string]string{"timezone": "timezone must be an IANA time zone name"},
+		},
+		{
+			name:     "too many links",
+			input:    PartialProfile{Links: &tooManyLinks},
+			wantErrs: map[string]string{"links": "at most 5 links are allowed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := tt.input.Validate()
+
+			assert.Equal(t, tt.wantErrs, errs, "error map mismatch")
+		})
+	}
+}
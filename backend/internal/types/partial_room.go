@@ -0,0 +1,99 @@
+package types
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"go-chat/internal/constants"
+	"go-chat/internal/models"
+)
+
+type PartialRoom struct {
+	Name            *string               `json:"name,omitempty"`
+	Topic           *string               `json:"topic,omitempty"`
+	Description     *string               `json:"description,omitempty"`
+	Avatar          *string               `json:"avatar,omitempty"`
+	PostingPolicy   *models.PostingPolicy `json:"posting_policy,omitempty"`
+	SlowModeSeconds *int                  `json:"slow_mode_seconds,omitempty"`
+	UpdatedAt       time.Time             `json:"updated_at"`
+}
+
+func (pr *PartialRoom) Validate() map[string]string {
+	errMap := make(map[string]string)
+
+	if pr.Name == nil && pr.Topic == nil && pr.Description == nil && pr.Avatar == nil && pr.PostingPolicy == nil &&
+		pr.SlowModeSeconds == nil {
+		errMap["fields"] = "at least one field must be provided to update the room"
+		return errMap
+	}
+
+	if pr.Name != nil && (len(*pr.Name) == 0 || len(*pr.Name) > constants.MaxRoomNameLength) {
+		errMap["name"] = fmt.Sprintf("name length must be between 1 and %d", constants.MaxRoomNameLength)
+	}
+
+	if pr.Topic != nil && len(*pr.Topic) > constants.MaxRoomTopicLength {
+		errMap["topic"] = fmt.Sprintf("topic length must be at most %d", constants.MaxRoomTopicLength)
+	}
+
+	if pr.Description != nil && len(*pr.Description) > constants.MaxRoomDescriptionLength {
+		errMap["description"] = fmt.Sprintf("description length must be at most %d", constants.MaxRoomDescriptionLength)
+	}
+
+	if pr.Avatar != nil && *pr.Avatar != "" {
+		if len(*pr.Avatar) > constants.MaxRoomAvatarLength {
+			errMap["avatar"] = fmt.Sprintf("avatar length must be at most %d", constants.MaxRoomAvatarLength)
+		} else if u, err := url.ParseRequestURI(*pr.Avatar); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			errMap["avatar"] = "avatar must be an http or https url"
+		}
+	}
+
+	if pr.PostingPolicy != nil && *pr.PostingPolicy != models.PostingPolicyEveryone && *pr.PostingPolicy != models.PostingPolicyAdmins {
+		errMap["posting_policy"] = "posting policy must be either everyone or admins"
+	}
+
+	if pr.SlowModeSeconds != nil && (*pr.SlowModeSeconds < 0 || *pr.SlowModeSeconds > constants.MaxRoomSlowModeSeconds) {
+		errMap["slow_mode_seconds"] = fmt.Sprintf("slow mode must be between 0 and %d seconds", constants.MaxRoomSlowModeSeconds)
+	}
+
+	return errMap
+}
+
+// Changes describes the provided fields for the system message recorded in the room history
+func (pr *PartialRoom) Changes() []string {
+	var changes []string
+
+	if pr.Name != nil {
+		changes = append(changes, fmt.Sprintf("renamed the room to %q", *pr.Name))
+	}
+
+	if pr.Topic != nil {
+		changes = append(changes, "changed the topic")
+	}
+
+	if pr.Description != nil {
+		changes = append(changes, "changed the description")
+	}
+
+	if pr.Avatar != nil {
+		changes = append(changes, "changed the avatar")
+	}
+
+	if pr.PostingPolicy != nil {
+		if *pr.PostingPolicy == models.PostingPolicyAdmins {
+			changes = append(changes, "limited posting to the host and admins")
+		} else {
+			changes = append(changes, "allowed everyone to post")
+		}
+	}
+
+	if pr.SlowModeSeconds != nil {
+		if *pr.SlowModeSeconds == 0 {
+			changes = append(changes, "turned off slow mode")
+		} else {
+			changes = append(changes, fmt.Sprintf("set slow mode to %d seconds", *pr.SlowModeSeconds))
+		}
+	}
+
+	return changes
+}
@@ -0,0 +1,24 @@
+package types
+
+type PartialRoomSettings struct {
+	Favorite *bool `json:"favorite,omitempty"`
+	Muted    *bool `json:"muted,omitempty"`
+	Hidden   *bool `json:"hidden,omitempty"`
+	// Position orders the room in the sidebar, a position of 0 clears it
+	Position *int `json:"position,omitempty"`
+}
+
+func (prs *PartialRoomSettings) Validate() map[string]string {
+	errMap := make(map[string]string)
+
+	if prs.Favorite == nil && prs.Muted == nil && prs.Hidden == nil && prs.Position == nil {
+		errMap["fields"] = "at least one field must be provided to update the room settings"
+		return errMap
+	}
+
+	if prs.Position != nil && *prs.Position < 0 {
+		errMap["position"] = "position cannot be negative"
+	}
+
+	return errMap
+}
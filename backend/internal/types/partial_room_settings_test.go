@@ -0,0 +1,49 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPartialRoomSettings_Validate(t *testing.T) {
+	favorite := true
+	position := 3
+	cleared := 0
+	negative := -1
+
+	tests := []struct {
+		name     string
+		input    PartialRoomSettings
+		wantErrs map[string]string
+	}{
+		{
+			name:     "no fields",
+			input:    PartialRoomSettings{},
+			wantErrs: map[string]string{"fields": "at least one field must be provided to update the room settings"},
+		},
+		{
+			name:     "valid fields",
+			input:    PartialRoomSettings{Favorite: &favorite, Position: &position},
+			wantErrs: map[string]string{},
+		},
+		{
+			name:     "cleared position",
+			input:    PartialRoomSettings{Position: &cleared},
+			wantErrs: map[string]string{},
+		},
+		{
+			name:     "negative position",
+			input:    PartialRoomSettings{Position: &negative},
+			wantErrs: map[string]string{"position": "position cannot be negative"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := tt.input.Validate()
+
+			assert.Equal(t, tt.wantErrs, errs, "error map mismatch")
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package types
+
+import (
+	"strings"
+	"testing"
+
+	"go-chat/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPartialRoom_Validate(t *testing.T) {
+	name := "general"
+	emptyName := ""
+	longTopic := strings.Repeat("a", 257)
+	avatar := "https://example.com/avatar.png"
+	badAvatar := "ftp://example.com/avatar.png"
+	clearedAvatar := ""
+	badPolicy := models.PostingPolicy("nobody")
+	slowMode := 0
+	badSlowMode := -1
+
+	tests := []struct {
+		name     string
+		input    PartialRoom
+		wantErrs map[string]string
+	}{
+		{
+			name:     "no fields",
+			input:    PartialRoom{},
+			wantErrs: map[string]string{"fields": "at least one field must be provided to update the room"},
+		},
+		{
+			name:     "valid fields",
+			input:    PartialRoom{Name: &name, Avatar: &avatar},
+			wantErrs: map[string]string{},
+		},
+		{
+			name:     "cleared avatar",
+			input:    PartialRoom{Avatar: &clearedAvatar},
+			wantErrs: map[string]string{},
+		},
+		{
+			name:     "disabled slow mode",
+			input:    PartialRoom{SlowModeSeconds: &slowMode},
+			wantErrs: map[string]string{},
+		},
+		{
+			name:  "invalid fields",
+			input: PartialRoom{Name: &emptyName, Topic: &longTopic, Avatar: &badAvatar, PostingPolicy: &badPolicy, SlowModeSeconds: &badSlowMode},
+			wantErrs: map[string]string{
+				"name":              "name length must be between 1 and 64",
+				"topic":             "topic length must be at most 256",
+				"avatar":            "avatar must be an http or https url",
+				"posting_policy":    "posting policy must be either everyone or admins",
+				"slow_mode_seconds": "slow mode must be between 0 and 21600 seconds",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := tt.input.Validate()
+
+			assert.Equal(t, tt.wantErrs, errs, "error map mismatch")
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package types
+
+import (
+	"fmt"
+	"time"
+
+	"go-chat/internal/constants"
+)
+
+type PartialWorkspace struct {
+	Name *string `json:"name,omitempty"`
+	// DmsContactsOnly restricts starting dms from the workspace to contacts
+	DmsContactsOnly *bool     `json:"dms_contacts_only,omitempty"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+func (pw *PartialWorkspace) Validate() map[string]string {
+	errMap := make(map[string]string)
+
+	if pw.Name == nil && pw.DmsContactsOnly == nil {
+		errMap["fields"] = "at least one field must be provided to update the workspace"
+		return errMap
+	}
+
+	if pw.Name != nil && (len(*pw.Name) == 0 || len(*pw.Name) > constants.MaxWorkspaceNameLength) {
+		errMap["name"] = fmt.Sprintf("name length must be between 1 and %d", constants.MaxWorkspaceNameLength)
+	}
+
+	return errMap
+}
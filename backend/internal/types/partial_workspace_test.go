@@ -0,0 +1,44 @@
+package types
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPartialWorkspace_Validate(t *testing.T) {
+	name := "acme"
+	longName := strings.Repeat("a", 65)
+	contactsOnly := true
+
+	tests := []struct {
+		name     string
+		input    PartialWorkspace
+		wantErrs map[string]string
+	}{
+		{
+			name:     "no fields",
+			input:    PartialWorkspace{},
+			wantErrs: map[string]string{"fields": "at least one field must be provided to update the workspace"},
+		},
+		{
+			name:     "valid fields",
+			input:    PartialWorkspace{Name: &name, DmsContactsOnly: &contactsOnly},
+			wantErrs: map[string]string{},
+		},
+		{
+			name:     "invalid name",
+			input:    PartialWorkspace{Name: &longName},
+			wantErrs: map[string]string{"name": "name length must be between 1 and 64"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := tt.input.Validate()
+
+			assert.Equal(t, tt.wantErrs, errs, "error map mismatch")
+		})
+	}
+}
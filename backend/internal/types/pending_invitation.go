@@ -0,0 +1,9 @@
+package types
+
+import "go-chat/internal/models"
+
+type PendingInvitation struct {
+	models.Invitation
+	RoomName       string         `json:"room_name" db:"room_name"`
+	InviterProfile models.Profile `json:"inviter_profile" db:"inviter_profile"`
+}
@@ -0,0 +1,98 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// PreferencesPatch is a json merge patch of the user's preferences, a null value removes the preference
+type PreferencesPatch map[string]json.RawMessage
+
+// preferenceSchema validates the value of every known preference
+var preferenceSchema = map[string]func(json.RawMessage) string{
+	"theme":                 enumPreference("light", "dark", "system"),
+	"message_density":       enumPreference("comfortable", "compact"),
+	"time_format":           enumPreference("12h", "24h"),
+	"notification_sounds":   boolPreference,
+	"desktop_notifications": boolPreference,
+	"send_on_enter":         boolPreference,
+}
+
+func (pp PreferencesPatch) Validate() map[string]string {
+	errMap := make(map[string]string)
+
+	if len(pp) == 0 {
+		errMap["fields"] = "at least one preference must be provided"
+		return errMap
+	}
+
+	for key, value := range pp {
+		validate, known := preferenceSchema[key]
+		if !known {
+			errMap[key] = "unknown preference"
+			continue
+		}
+
+		if isNullPreference(value) {
+			continue
+		}
+
+		if msg := validate(value); msg != "" {
+			errMap[key] = msg
+		}
+	}
+
+	return errMap
+}
+
+// Values returns the preferences the patch sets, encoded as a json object
+func (pp PreferencesPatch) Values() ([]byte, error) {
+	values := make(map[string]json.RawMessage, len(pp))
+	for key, value := range pp {
+		if !isNullPreference(value) {
+			values[key] = value
+		}
+	}
+
+	return json.Marshal(values)
+}
+
+// Removals returns the preferences the patch removes
+func (pp PreferencesPatch) Removals() []string {
+	removals := []string{}
+	for key, value := range pp {
+		if isNullPreference(value) {
+			removals = append(removals, key)
+		}
+	}
+
+	return removals
+}
+
+func isNullPreference(value json.RawMessage) bool {
+	trimmed := bytes.TrimSpace(value)
+	return len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null"))
+}
+
+func enumPreference(allowed ...string) func(json.RawMessage) string {
+	return func(value json.RawMessage) string {
+		var s string
+		if err := json.Unmarshal(value, &s); err != nil || !slices.Contains(allowed, s) {
+			return fmt.Sprintf("must be one of %s", strings.Join(allowed, ", "))
+		}
+
+		return ""
+	}
+}
+
+func boolPreference(value json.RawMessage) string {
+	var b bool
+	if err := json.Unmarshal(value, &b); err != nil {
+		return "must be a boolean"
+	}
+
+	return ""
+}
@@ -0,0 +1,57 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreferencesPatch_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		wantErrs map[string]string
+	}{
+		{
+			name:     "no preferences",
+			input:    `{}`,
+			wantErrs: map[string]string{"fields": "at least one preference must be provided"},
+		},
+		{
+			name:     "valid preferences",
+			input:    `{"theme": "dark", "notification_sounds": false, "message_density": null}`,
+			wantErrs: map[string]string{},
+		},
+		{
+			name:  "invalid preferences",
+			input: `{"theme": "blue", "send_on_enter": "yes", "font": "serif"}`,
+			wantErrs: map[string]string{
+				"theme":         "must be one of light, dark, system",
+				"send_on_enter": "must be a boolean",
+				"font":          "unknown preference",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var patch PreferencesPatch
+			assert.NoError(t, json.Unmarshal([]byte(tt.input), &patch))
+
+			errs := patch.Validate()
+
+			assert.Equal(t, tt.wantErrs, errs, "error map mismatch")
+		})
+	}
+}
+
+func TestPreferencesPatch_Split(t *testing.T) {
+	var patch PreferencesPatch
+	assert.NoError(t, json.Unmarshal([]byte(`{"theme": "dark", "time_format": null}`), &patch))
+
+	values, err := patch.Values()
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"theme": "dark"}`, string(values))
+	assert.Equal(t, []string{"time_format"}, patch.Removals())
+}
@@ -1,12 +1,37 @@
 package types
 
-import "github.com/google/uuid"
+import (
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+type ProfileSearchField string
+
+const (
+	ProfileSearchFieldUsername  ProfileSearchField = "username"
+	ProfileSearchFieldFirstName ProfileSearchField = "first_name"
+	ProfileSearchFieldLastName  ProfileSearchField = "last_name"
+)
+
+type ProfileSearchSort string
+
+const (
+	ProfileSearchSortRelevance ProfileSearchSort = "relevance"
+	ProfileSearchSortUsername  ProfileSearchSort = "username"
+	ProfileSearchSortCreatedAt ProfileSearchSort = "created_at"
+)
 
 type SearchProfilesOptions struct {
-	Username    string     `query:"username"`
-	Limit       int        `query:"limit"`
-	Offset      int        `query:"offset"`
-	ExcludeRoom *uuid.UUID `query:"excludeRoom"`
+	Query string `query:"query"`
+	// Username is accepted as an alias of Query for older clients
+	Username    string               `query:"username"`
+	Fields      []ProfileSearchField `query:"fields"`
+	Sort        ProfileSearchSort    `query:"sort"`
+	Order       string               `query:"order"`
+	Limit       int                  `query:"limit"`
+	Offset      int                  `query:"offset"`
+	ExcludeRoom *uuid.UUID           `query:"excludeRoom"`
 }
 
 const (
@@ -17,8 +42,52 @@ const (
 func (spo *SearchProfilesOptions) Validate() map[string]string {
 	errMap := make(map[string]string)
 
-	if len(spo.Username) == 0 {
-		errMap["username"] = "username cannot be empty"
+	if spo.Query == "" {
+		spo.Query = spo.Username
+	}
+
+	spo.Query = strings.TrimSpace(spo.Query)
+	if len(spo.Query) == 0 {
+		errMap["query"] = "query cannot be empty"
+	}
+
+	// fields may be repeated or given as a comma separated list
+	var fields []ProfileSearchField
+	for _, field := range spo.Fields {
+		for _, part := range strings.Split(string(field), ",") {
+			if part != "" {
+				fields = append(fields, ProfileSearchField(strings.TrimSpace(part)))
+			}
+		}
+	}
+
+	for _, field := range fields {
+		switch field {
+		case ProfileSearchFieldUsername, ProfileSearchFieldFirstName, ProfileSearchFieldLastName:
+		default:
+			errMap["fields"] = "fields must be any of username, first_name, last_name"
+		}
+	}
+
+	if len(fields) == 0 {
+		fields = []ProfileSearchField{ProfileSearchFieldUsername, ProfileSearchFieldFirstName, ProfileSearchFieldLastName}
+	}
+	spo.Fields = fields
+
+	switch spo.Sort {
+	case "":
+		spo.Sort = ProfileSearchSortRelevance
+	case ProfileSearchSortRelevance, ProfileSearchSortUsername, ProfileSearchSortCreatedAt:
+	default:
+		errMap["sort"] = "sort must be one of relevance, username, created_at"
+	}
+
+	switch spo.Order {
+	case "":
+		spo.Order = "asc"
+	case "asc", "desc":
+	default:
+		errMap["order"] = "order must be either asc or desc"
 	}
 
 	if spo.Limit < 1 {
@@ -31,3 +100,14 @@ func (spo *SearchProfilesOptions) Validate() map[string]string {
 
 	return errMap
 }
+
+// HasField reports whether the field is searched
+func (spo *SearchProfilesOptions) HasField(field ProfileSearchField) bool {
+	for _, f := range spo.Fields {
+		if f == field {
+			return true
+		}
+	}
+
+	return false
+}
@@ -7,6 +7,8 @@ import (
 )
 
 func TestSearchProfilesOptions_Validate(t *testing.T) {
+	allFields := []ProfileSearchField{ProfileSearchFieldUsername, ProfileSearchFieldFirstName, ProfileSearchFieldLastName}
+
 	tests := []struct {
 		name     string
 		input    SearchProfilesOptions
@@ -15,21 +17,44 @@ func TestSearchProfilesOptions_Validate(t *testing.T) {
 	}{
 		{
 			name:     "valid input",
-			input:    SearchProfilesOptions{Username: "alpha", Limit: 20, Offset: 5},
+			input:    SearchProfilesOptions{Query: "alpha", Limit: 20, Offset: 5},
+			wantErrs: map[string]string{},
+			wantVals: SearchProfilesOptions{Query: "alpha", Fields: allFields, Sort: ProfileSearchSortRelevance, Order: "asc", Limit: 20, Offset: 5},
+		},
+		{
+			name:     "username alias",
+			input:    SearchProfilesOptions{Username: "aaron kim", Limit: 20},
+			wantErrs: map[string]string{},
+			wantVals: SearchProfilesOptions{Query: "aaron kim", Fields: allFields, Sort: ProfileSearchSortRelevance, Order: "asc", Limit: 20},
+		},
+		{
+			name:     "comma separated fields",
+			input:    SearchProfilesOptions{Query: "kim", Fields: []ProfileSearchField{"first_name,last_name"}, Sort: ProfileSearchSortCreatedAt, Order: "desc", Limit: 20},
 			wantErrs: map[string]string{},
-			wantVals: SearchProfilesOptions{Username: "alpha", Limit: 20, Offset: 5},
+			wantVals: SearchProfilesOptions{
+				Query:  "kim",
+				Fields: []ProfileSearchField{ProfileSearchFieldFirstName, ProfileSearchFieldLastName},
+				Sort:   ProfileSearchSortCreatedAt,
+				Order:  "desc",
+				Limit:  20,
+			},
 		},
 		{
-			name:     "missing username",
-			input:    SearchProfilesOptions{Limit: 20, Offset: 0},
-			wantErrs: map[string]string{"username": "username cannot be empty"},
-			wantVals: SearchProfilesOptions{Username: "", Limit: 20, Offset: 0},
+			name:  "invalid options",
+			input: SearchProfilesOptions{Query: "  ", Fields: []ProfileSearchField{"email"}, Sort: "age", Order: "up", Limit: 20},
+			wantErrs: map[string]string{
+				"query":  "query cannot be empty",
+				"fields": "fields must be any of username, first_name, last_name",
+				"sort":   "sort must be one of relevance, username, created_at",
+				"order":  "order must be either asc or desc",
+			},
+			wantVals: SearchProfilesOptions{Query: "", Fields: []ProfileSearchField{"email"}, Sort: "age", Order: "up", Limit: 20},
 		},
 		{
 			name:     "invalid limit and offset",
-			input:    SearchProfilesOptions{Username: "bravo", Limit: 0, Offset: -5},
+			input:    SearchProfilesOptions{Query: "bravo", Limit: 0, Offset: -5},
 			wantErrs: map[string]string{},
-			wantVals: SearchProfilesOptions{Username: "bravo", Limit: defaultLimit, Offset: defaultOffset},
+			wantVals: SearchProfilesOptions{Query: "bravo", Fields: allFields, Sort: ProfileSearchSortRelevance, Order: "asc", Limit: defaultLimit, Offset: defaultOffset},
 		},
 	}
 
@@ -38,6 +63,10 @@ func TestSearchProfilesOptions_Validate(t *testing.T) {
 			errs := tt.input.Validate()
 
 			assert.Equal(t, tt.wantErrs, errs, "error map mismatch")
+			assert.Equal(t, tt.wantVals.Query, tt.input.Query, "query mismatch")
+			assert.Equal(t, tt.wantVals.Fields, tt.input.Fields, "fields mismatch")
+			assert.Equal(t, tt.wantVals.Sort, tt.input.Sort, "sort mismatch")
+			assert.Equal(t, tt.wantVals.Order, tt.input.Order, "order mismatch")
 			assert.Equal(t, tt.wantVals.Limit, tt.input.Limit, "limit mismatch")
 			assert.Equal(t, tt.wantVals.Offset, tt.input.Offset, "offset mismatch")
 		})
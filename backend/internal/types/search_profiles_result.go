@@ -0,0 +1,8 @@
+package types
+
+import "go-chat/internal/models"
+
+type SearchProfilesResult struct {
+	Profiles []models.Profile `json:"profiles"`
+	Total    int              `json:"total"`
+}
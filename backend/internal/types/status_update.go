@@ -0,0 +1,36 @@
+package types
+
+import (
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"go-chat/internal/constants"
+	"go-chat/internal/models"
+)
+
+type StatusUpdate struct {
+	Status    models.PresenceStatus `json:"status"`
+	Text      string                `json:"text"`
+	ExpiresAt *time.Time            `json:"expires_at"`
+}
+
+func (su *StatusUpdate) Validate() map[string]string {
+	errMap := make(map[string]string)
+
+	switch su.Status {
+	case models.PresenceStatusOnline, models.PresenceStatusAway, models.PresenceStatusDnd, models.PresenceStatusInvisible:
+	default:
+		errMap["status"] = "status must be one of online, away, dnd, invisible"
+	}
+
+	if utf8.RuneCountInString(su.Text) > constants.MaxPresenceStatusLength {
+		errMap["text"] = fmt.Sprintf("text length must be at most %d", constants.MaxPresenceStatusLength)
+	}
+
+	if su.ExpiresAt != nil && !su.ExpiresAt.After(time.Now()) {
+		errMap["expires_at"] = "expires_at must be in the future"
+	}
+
+	return errMap
+}
@@ -0,0 +1,50 @@
+package types
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go-chat/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusUpdate_Validate(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name     string
+		input    StatusUpdate
+		wantErrs map[string]string
+	}{
+		{
+			name:     "valid status",
+			input:    StatusUpdate{Status: models.PresenceStatusDnd, Text: "in a meeting", ExpiresAt: &future},
+			wantErrs: map[string]string{},
+		},
+		{
+			name:     "invisible without text",
+			input:    StatusUpdate{Status: models.PresenceStatusInvisible},
+			wantErrs: map[string]string{},
+		},
+		{
+			name:  "invalid fields",
+			input: StatusUpdate{Status: models.PresenceStatusOffline, Text: strings.Repeat("a", 129), ExpiresAt: &past},
+			wantErrs: map[string]string{
+				"status":     "status must be one of online, away, dnd, invisible",
+				"text":       "text length must be at most 128",
+				"expires_at": "expires_at must be in the future",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := tt.input.Validate()
+
+			assert.Equal(t, tt.wantErrs, errs, "error map mismatch")
+		})
+	}
+}
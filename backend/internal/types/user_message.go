@@ -4,7 +4,9 @@ import "go-chat/internal/models"
 
 type UserMessage struct {
 	models.Message
-	Username  string `json:"username" db:"username"`
-	FirstName string `json:"first_name" db:"first_name"`
-	LastName  string `json:"last_name" db:"last_name"`
+	Username      string `json:"username" db:"username"`
+	FirstName     string `json:"first_name" db:"first_name"`
+	LastName      string `json:"last_name" db:"last_name"`
+	AvatarUrl     string `json:"avatar_url" db:"avatar_url"`
+	AuthorBlocked bool   `json:"author_blocked" db:"author_blocked"`
 }
@@ -0,0 +1,10 @@
+package types
+
+import "go-chat/internal/models"
+
+type UserRoom struct {
+	models.Room
+	// Peer is the other member of a dm room and is nil for group rooms
+	Peer     *models.Profile     `json:"peer,omitempty" db:"peer"`
+	Settings models.RoomSettings `json:"settings" db:"settings"`
+}
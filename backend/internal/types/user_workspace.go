@@ -0,0 +1,8 @@
+package types
+
+import "go-chat/internal/models"
+
+type UserWorkspace struct {
+	models.Workspace
+	Role models.WorkspaceRole `json:"role" db:"role"`
+}
@@ -0,0 +1,59 @@
+package usernames
+
+import (
+	"strings"
+	"unicode"
+
+	"go-chat/internal/constants"
+)
+
+// Mentions returns the distinct usernames mentioned in the content, lowercased and in order of first appearance. A
+// mention is an '@' that starts a word followed by the longest run of characters the policy accepts as a username, so
+// it follows whatever pattern usernames are configured with.
+func (p *Policy) Mentions(content string) []string {
+	runes := []rune(content)
+
+	seen := make(map[string]struct{})
+	mentions := make([]string, 0)
+	for i, r := range runes {
+		if r != '@' || (i > 0 && continuesWord(runes[i-1])) {
+			continue
+		}
+
+		username := p.longestUsername(runes[i+1:])
+		if username == "" {
+			continue
+		}
+
+		username = strings.ToLower(username)
+		if _, exists := seen[username]; exists {
+			continue
+		}
+
+		seen[username] = struct{}{}
+		mentions = append(mentions, username)
+	}
+
+	return mentions
+}
+
+// longestUsername returns the longest prefix of the word that the policy allows as a username
+func (p *Policy) longestUsername(word []rune) string {
+	end := 0
+	for end < len(word) && end < constants.MaxUsernameLength && !unicode.IsSpace(word[end]) && word[end] != '@' {
+		end++
+	}
+
+	for ; end > 0; end-- {
+		if candidate := string(word[:end]); p.allowed.MatchString(candidate) {
+			return candidate
+		}
+	}
+
+	return ""
+}
+
+// continuesWord reports whether an '@' after the rune is part of a word, as in an email address, rather than a mention
+func continuesWord(r rune) bool {
+	return r == '@' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r)
+}
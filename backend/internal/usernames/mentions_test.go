@@ -0,0 +1,68 @@
+package usernames
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy_Mentions(t *testing.T) {
+	policy, err := NewPolicy(&Config{AllowedPattern: `^[A-Za-z0-9](?:[A-Za-z0-9_.-]*[A-Za-z0-9])?$`})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name:    "no mentions",
+			content: "hello there",
+			want:    []string{},
+		},
+		{
+			name:    "single mention",
+			content: "@aaron.kim hello",
+			want:    []string{"aaron.kim"},
+		},
+		{
+			name:    "trailing punctuation",
+			content: "thanks @aaron.kim.",
+			want:    []string{"aaron.kim"},
+		},
+		{
+			name:    "lowercased and deduplicated",
+			content: "@Aaron and @aaron, meet @jane_doe",
+			want:    []string{"aaron", "jane_doe"},
+		},
+		{
+			name:    "email address",
+			content: "mail me at aaron@example.com",
+			want:    []string{},
+		},
+		{
+			name:    "double at",
+			content: "@@aaron",
+			want:    []string{},
+		},
+		{
+			name:    "inside parentheses",
+			content: "(@aaron)",
+			want:    []string{"aaron"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, policy.Mentions(tt.content))
+		})
+	}
+}
+
+func TestPolicy_MentionsFollowConfiguredPattern(t *testing.T) {
+	policy, err := NewPolicy(&Config{AllowedPattern: `^[\p{L}0-9][\p{L}0-9+'_.-]*$`})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"o'brien", "zoë+work"}, policy.Mentions("ask @O'Brien or @zoë+work, not me@x"))
+}
@@ -0,0 +1,61 @@
+package usernames
+
+import (
+	"fmt"
+	"regexp"
+	"unicode/utf8"
+
+	"go-chat/internal/constants"
+)
+
+// Policy decides which usernames may be registered
+type Policy struct {
+	allowed  *regexp.Regexp
+	reserved map[string]struct{}
+}
+
+type Config struct {
+	// AllowedPattern is a regular expression every username must fully match
+	AllowedPattern string
+	// Reserved names cannot be registered, nor can names confusable with them
+	Reserved []string
+}
+
+func NewPolicy(cfg *Config) (*Policy, error) {
+	allowed, err := regexp.Compile(cfg.AllowedPattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid allowed username pattern: %w", err)
+	}
+
+	reserved := make(map[string]struct{}, len(cfg.Reserved))
+	for _, name := range cfg.Reserved {
+		reserved[reservedSkeleton(name)] = struct{}{}
+	}
+
+	return &Policy{
+		allowed:  allowed,
+		reserved: reserved,
+	}, nil
+}
+
+func (p *Policy) Validate(username string) map[string]string {
+	errMap := make(map[string]string)
+
+	length := utf8.RuneCountInString(username)
+	switch {
+	case length < constants.MinUsernameLength || length > constants.MaxUsernameLength:
+		errMap["username"] = fmt.Sprintf(
+			"username length must be between %d and %d",
+			constants.MinUsernameLength,
+			constants.MaxUsernameLength,
+		)
+	case !p.allowed.MatchString(username):
+		errMap["username"] = "username contains characters that are not allowed"
+	default:
+		if _, reserved := p.reserved[reservedSkeleton(username)]; reserved {
+			errMap["username"] = "username is reserved"
+		}
+	}
+
+	return errMap
+}
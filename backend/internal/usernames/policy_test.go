@@ -0,0 +1,97 @@
+package usernames
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy_Validate(t *testing.T) {
+	policy, err := NewPolicy(&Config{
+		AllowedPattern: `^[A-Za-z0-9](?:[A-Za-z0-9_.-]*[A-Za-z0-9])?$`,
+		Reserved:       []string{"admin", "support"},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		input    string
+		wantErrs map[string]string
+	}{
+		{
+			name:     "valid username",
+			input:    "aaron.kim",
+			wantErrs: map[string]string{},
+		},
+		{
+			name:     "too short",
+			input:    "abc",
+			wantErrs: map[string]string{"username": "username length must be between 4 and 16"},
+		},
+		{
+			name:     "whitespace",
+			input:    "    ",
+			wantErrs: map[string]string{"username": "username contains characters that are not allowed"},
+		},
+		{
+			name:     "emoji",
+			input:    "hi😀there",
+			wantErrs: map[string]string{"username": "username contains characters that are not allowed"},
+		},
+		{
+			name:     "reserved",
+			input:    "Admin",
+			wantErrs: map[string]string{"username": "username is reserved"},
+		},
+		{
+			name:     "confusable with reserved",
+			input:    "supp0rt",
+			wantErrs: map[string]string{"username": "username is reserved"},
+		},
+		{
+			name:     "sequence confusable with reserved",
+			input:    "adrnin",
+			wantErrs: map[string]string{"username": "username is reserved"},
+		},
+		{
+			name:     "sequences allowed in ordinary names",
+			input:    "vernon",
+			wantErrs: map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := policy.Validate(tt.input)
+
+			assert.Equal(t, tt.wantErrs, errs, "error map mismatch")
+		})
+	}
+}
+
+func TestSkeleton(t *testing.T) {
+	tests := []struct {
+		name  string
+		a     string
+		b     string
+		equal bool
+	}{
+		{name: "case", a: "AaronKim", b: "aaronkim", equal: true},
+		{name: "cyrillic a", a: "аaron", b: "aaron", equal: true},
+		{name: "fullwidth", a: "ａａｒｏｎ", b: "aaron", equal: true},
+		{name: "uppercase cyrillic", a: "АARON", b: "aaron", equal: true},
+		{name: "uppercase i", a: "Ian", b: "ian", equal: true},
+		{name: "digits are not letters", a: "john1", b: "johnl", equal: false},
+		{name: "zero is not o", a: "bob0", b: "bobo", equal: false},
+		{name: "rn is not m", a: "vernon", b: "vemon", equal: false},
+		{name: "vv is not w", a: "vvv", b: "wv", equal: false},
+		{name: "different names", a: "aaron", b: "baron", equal: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.equal, Skeleton(tt.a) == Skeleton(tt.b), "skeleton equality mismatch")
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package usernames
+
+import (
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// confusables maps lowercase letters of other scripts to the latin letter they are commonly mistaken for. Usernames
+// are lowercased before they are mapped, so uppercase look-alikes are covered through their lowercase forms.
+var confusables = map[rune]rune{
+	// cyrillic
+	'а': 'a', 'в': 'b', 'е': 'e', 'ё': 'e', 'к': 'k', 'м': 'm', 'н': 'h', 'о': 'o', 'р': 'p', 'с': 'c', 'т': 't',
+	'у': 'y', 'х': 'x', 'ѕ': 's', 'і': 'i', 'ї': 'i', 'ј': 'j', 'һ': 'h', 'ԁ': 'd', 'ԛ': 'q', 'ԝ': 'w', 'ӏ': 'l',
+	// greek
+	'α': 'a', 'β': 'b', 'ε': 'e', 'ι': 'i', 'κ': 'k', 'ν': 'v', 'ο': 'o', 'ρ': 'p', 'τ': 't', 'υ': 'u', 'χ': 'x',
+}
+
+// lookalikes are digits, symbols and letter sequences that render like a latin letter. Ordinary names such as john1
+// and johnl or vernon and vemon differ only by them, so they are left out of the skeleton and only used to guard
+// reserved names.
+var lookalikes = strings.NewReplacer("0", "o", "1", "l", "|", "l", "rn", "m", "vv", "w")
+
+// Skeleton reduces a username to a canonical form so that names which look alike, such as ones differing only in
+// case or in a cyrillic 'а' for a latin 'a', share the same skeleton
+func Skeleton(username string) string {
+	normalized := strings.ToLower(norm.NFKC.String(username))
+
+	var b strings.Builder
+	b.Grow(len(normalized))
+	for _, r := range normalized {
+		if mapped, ok := confusables[r]; ok {
+			r = mapped
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// reservedSkeleton is the skeleton with the look-alikes folded as well, which is stricter than the uniqueness check
+// so that names such as "supp0rt" or "adrnin" cannot pass for a reserved name
+func reservedSkeleton(username string) string {
+	return lookalikes.Replace(Skeleton(username))
+}
@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"image"
+	"image/draw"
+)
+
+// ResizeSquare center-crops the image to a square once and scales the crop to each of the sizes, returning the
+// images in the same order. Each destination pixel is the average of the source pixels it covers, which keeps
+// downscaled images smooth without pulling in an imaging library.
+func ResizeSquare(img image.Image, sizes ...int) []*image.RGBA {
+	bounds := img.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+
+	src := image.NewRGBA(image.Rect(0, 0, side, side))
+	offset := image.Pt(bounds.Min.X+(bounds.Dx()-side)/2, bounds.Min.Y+(bounds.Dy()-side)/2)
+	draw.Draw(src, src.Bounds(), img, offset, draw.Src)
+
+	resized := make([]*image.RGBA, 0, len(sizes))
+	for _, size := range sizes {
+		resized = append(resized, scaleSquare(src, side, size))
+	}
+
+	return resized
+}
+
+// scaleSquare scales a side x side image to size x size
+func scaleSquare(src *image.RGBA, side int, size int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := range size {
+		y0 := y * side / size
+		y1 := max((y+1)*side/size, y0+1)
+
+		for x := range size {
+			x0 := x * side / size
+			x1 := max((x+1)*side/size, x0+1)
+
+			var r, g, b, a, n uint32
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					i := src.PixOffset(sx, sy)
+					r += uint32(src.Pix[i])
+					g += uint32(src.Pix[i+1])
+					b += uint32(src.Pix[i+2])
+					a += uint32(src.Pix[i+3])
+					n++
+				}
+			}
+
+			i := dst.PixOffset(x, y)
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	red   = color.RGBA{R: 255, A: 255}
+	green = color.RGBA{G: 255, A: 255}
+	blue  = color.RGBA{B: 255, A: 255}
+)
+
+// stripes returns an image of the given height made of one column per colour
+func stripes(height int, colors ...color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, len(colors), height))
+	for x, c := range colors {
+		for y := range height {
+			img.SetRGBA(x, y, c)
+		}
+	}
+
+	return img
+}
+
+func TestResizeSquare(t *testing.T) {
+	wide := stripes(2, red, green, green, blue)
+	shifted := wide.SubImage(image.Rect(1, 0, 3, 2))
+
+	tests := []struct {
+		name  string
+		img   image.Image
+		sizes []int
+		want  []color.RGBA
+	}{
+		{
+			name:  "crops landscape images to the center",
+			img:   stripes(2, red, green, green, blue),
+			sizes: []int{1},
+			want:  []color.RGBA{green},
+		},
+		{
+			name:  "averages the pixels each destination pixel covers",
+			img:   stripes(2, red, blue),
+			sizes: []int{1},
+			want:  []color.RGBA{{R: 127, B: 127, A: 255}},
+		},
+		{
+			name:  "crops portrait images to the center",
+			img:   stripes(3, green),
+			sizes: []int{2},
+			want:  []color.RGBA{green},
+		},
+		{
+			name:  "honours bounds that do not start at the origin",
+			img:   shifted,
+			sizes: []int{1},
+			want:  []color.RGBA{green},
+		},
+		{
+			name:  "upscales small images",
+			img:   stripes(1, blue),
+			sizes: []int{3},
+			want:  []color.RGBA{blue},
+		},
+		{
+			name:  "resizes to every size in order",
+			img:   stripes(2, green, green),
+			sizes: []int{4, 1, 2},
+			want:  []color.RGBA{green, green, green},
+		},
+		{
+			name:  "no sizes",
+			img:   stripes(1, red),
+			sizes: nil,
+			want:  []color.RGBA{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resized := ResizeSquare(tt.img, tt.sizes...)
+			require.Len(t, resized, len(tt.want))
+
+			for i, img := range resized {
+				size := tt.sizes[i]
+				assert.Equal(t, image.Rect(0, 0, size, size), img.Bounds())
+
+				// every test image resizes to a single flat colour
+				for y := range size {
+					for x := range size {
+						assert.Equal(t, tt.want[i], img.RGBAAt(x, y))
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package xcontext
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type workspaceIdKey struct{}
+
+func SetWorkspaceId(c *fiber.Ctx, workspaceId uuid.UUID) {
+	c.Locals(workspaceIdKey{}, workspaceId)
+}
+
+func GetWorkspaceId(c *fiber.Ctx) (uuid.UUID, error) {
+	workspaceId, ok := c.Locals(workspaceIdKey{}).(uuid.UUID)
+	if !ok {
+		return uuid.UUID{}, fmt.Errorf("failed to retrieve workspace id from fiber context locals")
+	}
+
+	return workspaceId, nil
+}
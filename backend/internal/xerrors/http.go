@@ -34,6 +34,14 @@ func BadRequestError(message string) HTTPError {
 	return NewHTTPError(http.StatusBadRequest, errors.New(message))
 }
 
+func ForbiddenError(message string) HTTPError {
+	return NewHTTPError(http.StatusForbidden, errors.New(message))
+}
+
+func GoneError(message string) HTTPError {
+	return NewHTTPError(http.StatusGone, errors.New(message))
+}
+
 func NotFoundError(entity string, args map[string]string) HTTPError {
 	var parts []string
 	for k, v := range args {
@@ -42,6 +50,12 @@ func NotFoundError(entity string, args map[string]string) HTTPError {
 	return NewHTTPError(http.StatusNotFound, fmt.Errorf("%s with %s not found", entity, strings.Join(parts, ", ")))
 }
 
+// IsNotFound reports whether the error is a not found HTTPError
+func IsNotFound(err error) bool {
+	var httpErr HTTPError
+	return errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound
+}
+
 func InvalidJSON() HTTPError {
 	return NewHTTPError(http.StatusBadRequest, errors.New("invalid JSON request data"))
 }
@@ -50,6 +64,26 @@ func ConflictError(entity, key, value string) HTTPError {
 	return NewHTTPError(http.StatusConflict, fmt.Errorf("%s with %s=%s already exists", entity, key, value))
 }
 
+func RoomArchivedError() HTTPError {
+	return NewHTTPError(http.StatusConflict, errors.New("room is archived"))
+}
+
+func RoomBannedError() HTTPError {
+	return NewHTTPError(http.StatusForbidden, errors.New("banned from this room"))
+}
+
+func ConfusableUsernameError() HTTPError {
+	return NewHTTPError(http.StatusConflict, errors.New("username is too similar to an existing username"))
+}
+
+func ContactRequestDeclinedError() HTTPError {
+	return NewHTTPError(http.StatusTooManyRequests, errors.New("contact request was declined recently"))
+}
+
+func PreconditionFailedError(message string) HTTPError {
+	return NewHTTPError(http.StatusPreconditionFailed, errors.New(message))
+}
+
 func UnprocessableEntityError(errors map[string]string) HTTPError {
 	return HTTPError{
 		StatusCode: http.StatusUnprocessableEntity,
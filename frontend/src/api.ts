@@ -184,7 +184,7 @@ export const searchProfiles = async (
     throw new Error(`failed to create profile for user`);
   }
 
-  return z.array(ProfileSchema).parse(res.data);
+  return z.array(ProfileSchema).parse(res.data.profiles);
 };
 
 export const getProfilesByRoomId = async (
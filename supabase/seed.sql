@@ -1,37 +1,330 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
 CREATE TABLE profiles (
     user_id UUID PRIMARY KEY,
     username TEXT UNIQUE NOT NULL,
+    -- confusable skeleton of the username, see usernames.Skeleton
+    username_skeleton TEXT NOT NULL,
     first_name TEXT NOT NULL,
     last_name TEXT NOT NULL,
+    avatar_url TEXT NOT NULL DEFAULT '',
+    bio TEXT NOT NULL DEFAULT '',
+    pronouns TEXT NOT NULL DEFAULT '',
+    -- iana time zone name
+    timezone TEXT NOT NULL DEFAULT '',
+    -- bcp 47 language tag
+    locale TEXT NOT NULL DEFAULT '',
+    links TEXT[] NOT NULL DEFAULT '{}',
+    -- client settings synced across devices, the version backs the preferences etag
+    preferences JSONB NOT NULL DEFAULT '{}',
+    preferences_version BIGINT NOT NULL DEFAULT 0,
+    last_seen_at TIMESTAMPTZ,
     created_at TIMESTAMPTZ NOT NULL,
     updated_at TIMESTAMPTZ NOT NULL,
-    FOREIGN KEY (user_id) REFERENCES auth.users(id) ON DELETE CASCADE
+    FOREIGN KEY (user_id) REFERENCES auth.users(id) ON DELETE CASCADE,
+    CONSTRAINT profiles_username_skeleton_key UNIQUE (username_skeleton)
+);
+
+CREATE UNIQUE INDEX profiles_username_lower_key ON profiles (lower(username));
+
+-- trigram indexes back the fuzzy profile search, the full name expression must match profileFullNameExpr
+CREATE INDEX profiles_username_trgm_idx ON profiles USING gin (username gin_trgm_ops);
+CREATE INDEX profiles_first_name_trgm_idx ON profiles USING gin (first_name gin_trgm_ops);
+CREATE INDEX profiles_last_name_trgm_idx ON profiles USING gin (last_name gin_trgm_ops);
+CREATE INDEX profiles_full_name_trgm_idx ON profiles USING gin ((first_name || ' ' || last_name) gin_trgm_ops);
+
+CREATE TABLE profile_avatars (
+    user_id UUID,
+    size INT CHECK (size > 0),
+    data BYTEA NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (user_id, size),
+    FOREIGN KEY (user_id) REFERENCES profiles(user_id) ON DELETE CASCADE
+);
+
+CREATE TABLE workspaces (
+    id UUID PRIMARY KEY,
+    name TEXT NOT NULL,
+    dms_contacts_only BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE workspace_members (
+    workspace_id UUID,
+    user_id UUID,
+    role TEXT NOT NULL DEFAULT 'member' CHECK (role IN ('owner', 'member')),
+    joined_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (workspace_id, user_id),
+    FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES profiles(user_id) ON DELETE CASCADE
 );
 
 CREATE TABLE rooms (
     id UUID PRIMARY KEY,
-    host UUID NOT NULL,
+    host UUID,
     name TEXT NOT NULL,
+    kind TEXT NOT NULL DEFAULT 'group' CHECK (kind IN ('group', 'dm')),
+    visibility TEXT NOT NULL DEFAULT 'private' CHECK (visibility IN ('private', 'public')),
+    topic TEXT NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    avatar TEXT NOT NULL DEFAULT '',
+    archived_at TIMESTAMPTZ,
+    posting_policy TEXT NOT NULL DEFAULT 'everyone' CHECK (posting_policy IN ('everyone', 'admins')),
+    slow_mode_seconds INT NOT NULL DEFAULT 0 CHECK (slow_mode_seconds >= 0),
+    workspace_id UUID,
     created_at TIMESTAMPTZ NOT NULL,
     updated_at TIMESTAMPTZ NOT NULL,
-    FOREIGN KEY (host) REFERENCES profiles(user_id) ON DELETE SET NULL
+    CHECK ((kind = 'dm') = (workspace_id IS NULL)),
+    FOREIGN KEY (host) REFERENCES profiles(user_id) ON DELETE SET NULL,
+    FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE
 );
 
+-- author is cleared when its profile is deleted so the message stays in the room anonymized
 CREATE TABLE messages (
     id UUID PRIMARY KEY,
     room_id UUID NOT NULL,
-    author UUID NOT NULL,
+    author UUID,
     content TEXT NOT NULL,
+    kind TEXT NOT NULL DEFAULT 'user' CHECK (kind IN ('user', 'system')),
     created_at TIMESTAMPTZ NOT NULL,
     updated_at TIMESTAMPTZ NOT NULL,
     FOREIGN KEY (room_id) REFERENCES rooms(id) ON DELETE CASCADE,
     FOREIGN KEY (author) REFERENCES profiles(user_id) ON DELETE SET NULL
 );
 
+CREATE INDEX messages_room_id_author_created_at_idx ON messages (room_id, author, created_at DESC);
+
 CREATE TABLE users_rooms (
     user_id UUID,
     room_id UUID,
+    role TEXT NOT NULL DEFAULT 'member' CHECK (role IN ('member', 'admin')),
+    joined_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    favorite BOOLEAN NOT NULL DEFAULT false,
+    muted BOOLEAN NOT NULL DEFAULT false,
+    hidden BOOLEAN NOT NULL DEFAULT false,
+    position INT CHECK (position IS NULL OR position > 0),
     PRIMARY KEY (user_id, room_id),
     FOREIGN KEY (user_id) REFERENCES profiles(user_id) ON DELETE CASCADE,
     FOREIGN KEY (room_id) REFERENCES rooms(id) ON DELETE CASCADE
 );
+
+CREATE INDEX users_rooms_room_id_idx ON users_rooms (room_id);
+
+CREATE TABLE dms (
+    room_id UUID PRIMARY KEY,
+    user_a UUID NOT NULL,
+    user_b UUID NOT NULL,
+    UNIQUE (user_a, user_b),
+    CHECK (user_a < user_b),
+    FOREIGN KEY (room_id) REFERENCES rooms(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_a) REFERENCES profiles(user_id) ON DELETE CASCADE,
+    FOREIGN KEY (user_b) REFERENCES profiles(user_id) ON DELETE CASCADE
+);
+
+CREATE TABLE room_invites (
+    code TEXT PRIMARY KEY,
+    room_id UUID NOT NULL,
+    created_by UUID NOT NULL,
+    max_uses INT CHECK (max_uses IS NULL OR max_uses > 0),
+    uses INT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL,
+    FOREIGN KEY (room_id) REFERENCES rooms(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES profiles(user_id) ON DELETE CASCADE
+);
+
+CREATE TABLE room_invitations (
+    id UUID PRIMARY KEY,
+    room_id UUID NOT NULL,
+    inviter UUID NOT NULL,
+    invitee UUID NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'declined')),
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    FOREIGN KEY (room_id) REFERENCES rooms(id) ON DELETE CASCADE,
+    FOREIGN KEY (inviter) REFERENCES profiles(user_id) ON DELETE CASCADE,
+    FOREIGN KEY (invitee) REFERENCES profiles(user_id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX room_invitations_pending_key ON room_invitations (room_id, invitee) WHERE status = 'pending';
+
+CREATE TABLE room_bans (
+    room_id UUID,
+    user_id UUID,
+    banned_by UUID,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (room_id, user_id),
+    FOREIGN KEY (room_id) REFERENCES rooms(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES profiles(user_id) ON DELETE CASCADE,
+    FOREIGN KEY (banned_by) REFERENCES profiles(user_id) ON DELETE SET NULL
+);
+
+CREATE TABLE room_mutes (
+    room_id UUID,
+    user_id UUID,
+    muted_by UUID,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (room_id, user_id),
+    FOREIGN KEY (room_id) REFERENCES rooms(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES profiles(user_id) ON DELETE CASCADE,
+    FOREIGN KEY (muted_by) REFERENCES profiles(user_id) ON DELETE SET NULL
+);
+
+CREATE TABLE user_blocks (
+    blocker UUID,
+    blocked UUID,
+    created_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (blocker, blocked),
+    CHECK (blocker <> blocked),
+    FOREIGN KEY (blocker) REFERENCES profiles(user_id) ON DELETE CASCADE,
+    FOREIGN KEY (blocked) REFERENCES profiles(user_id) ON DELETE CASCADE
+);
+
+CREATE INDEX user_blocks_blocked_idx ON user_blocks (blocked);
+
+CREATE TABLE contacts (
+    requester UUID,
+    recipient UUID,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'declined')),
+    created_at TIMESTAMPTZ NOT NULL,
+    accepted_at TIMESTAMPTZ,
+    declined_at TIMESTAMPTZ,
+    PRIMARY KEY (requester, recipient),
+    CHECK (requester <> recipient),
+    CHECK ((status = 'accepted') = (accepted_at IS NOT NULL)),
+    CHECK ((status = 'declined') = (declined_at IS NOT NULL)),
+    FOREIGN KEY (requester) REFERENCES profiles(user_id) ON DELETE CASCADE,
+    FOREIGN KEY (recipient) REFERENCES profiles(user_id) ON DELETE CASCADE
+);
+
+-- a pair of users has at most one contact row regardless of who sent the request
+CREATE UNIQUE INDEX contacts_pair_idx ON contacts (LEAST(requester, recipient), GREATEST(requester, recipient));
+CREATE INDEX contacts_recipient_idx ON contacts (recipient);
+
+-- audit entries outlive the rooms and profiles they mention so they carry no foreign keys
+CREATE TABLE room_audit_log (
+    id UUID PRIMARY KEY,
+    room_id UUID NOT NULL,
+    actor UUID,
+    action TEXT NOT NULL,
+    target UUID,
+    details JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX room_audit_log_room_id_created_at_idx ON room_audit_log (room_id, created_at DESC);
+
+-- the host and admins of a room when it was deleted, who keep access to its audit log
+CREATE TABLE room_audit_readers (
+    room_id UUID,
+    user_id UUID,
+    PRIMARY KEY (room_id, user_id),
+    FOREIGN KEY (user_id) REFERENCES profiles(user_id) ON DELETE CASCADE
+);
+
+CREATE INDEX room_audit_readers_user_id_idx ON room_audit_readers (user_id);
+
+-- entries are append-only, except that the actor and target may be cleared so deleted accounts can be anonymised
+CREATE FUNCTION reject_room_audit_log_change() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'UPDATE'
+        AND NEW.id = OLD.id
+        AND NEW.room_id = OLD.room_id
+        AND NEW.action = OLD.action
+        AND NEW.details = OLD.details
+        AND NEW.created_at = OLD.created_at
+        AND (NEW.actor IS NULL OR NEW.actor = OLD.actor)
+        AND (NEW.target IS NULL OR NEW.target = OLD.target)
+    THEN
+        RETURN NEW;
+    END IF;
+
+    RAISE EXCEPTION 'room_audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER room_audit_log_append_only
+BEFORE UPDATE OR DELETE ON room_audit_log
+FOR EACH ROW EXECUTE FUNCTION reject_room_audit_log_change();
+
+-- hand hosted rooms to their longest-standing remaining member before a host's profile is removed,
+-- archiving rooms that have no one left to take over
+CREATE FUNCTION reassign_hosted_rooms() RETURNS TRIGGER AS $$
+DECLARE
+    hosted_room_id UUID;
+    successor UUID;
+BEGIN
+    FOR hosted_room_id IN SELECT id FROM rooms WHERE host = OLD.user_id LOOP
+        SELECT user_id INTO successor
+        FROM users_rooms
+        WHERE room_id = hosted_room_id AND user_id <> OLD.user_id
+        ORDER BY joined_at ASC, user_id ASC
+        LIMIT 1;
+
+        IF successor IS NULL THEN
+            UPDATE rooms SET host = NULL, archived_at = now(), updated_at = now() WHERE id = hosted_room_id;
+        ELSE
+            UPDATE rooms SET host = successor, updated_at = now() WHERE id = hosted_room_id;
+        END IF;
+    END LOOP;
+
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER profiles_reassign_hosted_rooms
+BEFORE DELETE ON profiles
+FOR EACH ROW EXECUTE FUNCTION reassign_hosted_rooms();
+
+-- promote the longest-standing remaining member of workspaces that would be left without an owner when an owner's
+-- profile is removed, deleting workspaces that have no one left
+CREATE FUNCTION reassign_owned_workspaces() RETURNS TRIGGER AS $$
+DECLARE
+    owned_workspace_id UUID;
+    successor UUID;
+BEGIN
+    FOR owned_workspace_id IN
+        SELECT workspace_id FROM workspace_members AS wm
+        WHERE wm.user_id = OLD.user_id AND wm.role = 'owner'
+          AND NOT EXISTS (
+            SELECT 1 FROM workspace_members
+            WHERE workspace_id = wm.workspace_id AND user_id <> OLD.user_id AND role = 'owner'
+          )
+    LOOP
+        SELECT user_id INTO successor
+        FROM workspace_members
+        WHERE workspace_id = owned_workspace_id AND user_id <> OLD.user_id
+        ORDER BY joined_at ASC, user_id ASC
+        LIMIT 1;
+
+        IF successor IS NULL THEN
+            DELETE FROM workspaces WHERE id = owned_workspace_id;
+        ELSE
+            UPDATE workspace_members SET role = 'owner' WHERE workspace_id = owned_workspace_id AND user_id = successor;
+        END IF;
+    END LOOP;
+
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER profiles_reassign_owned_workspaces
+BEFORE DELETE ON profiles
+FOR EACH ROW EXECUTE FUNCTION reassign_owned_workspaces();
+
+-- anonymise the audit log entries of a user whose profile is removed, keeping the entries themselves
+CREATE FUNCTION anonymise_room_audit_log() RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE room_audit_log SET actor = NULL WHERE actor = OLD.user_id;
+    UPDATE room_audit_log SET target = NULL WHERE target = OLD.user_id;
+
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER profiles_anonymise_room_audit_log
+BEFORE DELETE ON profiles
+FOR EACH ROW EXECUTE FUNCTION anonymise_room_audit_log();